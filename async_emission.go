@@ -0,0 +1,118 @@
+package grpc_zerolog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// dropReportInterval is the minimal interval between the reports of the dropped log lines of AsyncWriter
+const dropReportInterval = 10 * time.Second
+
+// AsyncWriter writes the log lines to the underlying writer in a background goroutine, so the calls never block
+// on the slow writer. The lines are queued in a bounded buffer, when it is full the new lines are dropped and counted.
+// The number of the dropped lines is reported by a Warn line at most once per 10 seconds and when AsyncWriter closes.
+// Close must be called on shutdown to write the queued lines.
+type AsyncWriter struct {
+	dropped  int64
+	reported int64 // the dropped lines already reported, accessed only by the background goroutine
+
+	w      io.Writer
+	lines  chan []byte
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewAsyncWriter returns AsyncWriter writing to w with the buffer for bufferSize lines
+func NewAsyncWriter(w io.Writer, bufferSize int) *AsyncWriter {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	aw := &AsyncWriter{w: w, lines: make(chan []byte, bufferSize), done: make(chan struct{})}
+	go aw.run()
+	return aw
+}
+
+// WithAsyncEmission makes the interceptor write the log lines through w, see AsyncWriter. The writer of the logger
+// given to the interceptor constructor is replaced by w (zerolog doesn't expose it), so w is created with
+// the writer of the logger, e.g.
+//
+//	w := grpc_zerolog.NewAsyncWriter(os.Stderr, 10000)
+//	defer w.Close()
+//	grpc_zerolog.NewUnaryServerInterceptor(logger, grpc_zerolog.WithAsyncEmission(w))
+//
+// It replaces the writer set by WithWriteErrorHandler, only the last of the two options applies.
+func WithAsyncEmission(w *AsyncWriter) Option {
+	return func(o *options) {
+		o.output = w
+	}
+}
+
+// Write queues the copy of p, it never blocks. The lines written after Close are dropped.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		atomic.AddInt64(&w.dropped, 1)
+		return len(p), nil
+	}
+	line := make([]byte, len(p))
+	copy(line, p)
+	select {
+	case w.lines <- line:
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of the log lines dropped so far
+func (w *AsyncWriter) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// Close writes the queued lines and stops the background goroutine, it is safe to call it more than once
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.lines)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(dropReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-w.lines:
+			if !ok {
+				w.reportDropped()
+				return
+			}
+			_, _ = w.w.Write(line)
+		case <-ticker.C:
+			w.reportDropped()
+		}
+	}
+}
+
+// reportDropped logs the number of the lines dropped since the previous report
+func (w *AsyncWriter) reportDropped() {
+	dropped := atomic.LoadInt64(&w.dropped)
+	if dropped == w.reported {
+		return
+	}
+	l := zerolog.New(w.w)
+	l.Warn().Int64("grpc.log.dropped", dropped-w.reported).Int64("grpc.log.dropped_total", dropped).
+		Msg("dropped log lines, the async buffer is full")
+	w.reported = dropped
+}
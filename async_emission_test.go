@@ -0,0 +1,48 @@
+package grpc_zerolog_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pereslava/grpc_zerolog"
+	"github.com/rs/zerolog"
+)
+
+// blockingWriter blocks the writes until it is released
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	b       bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func TestAsyncWriterDropsWhenFullAndDrainsOnClose(t *testing.T) {
+	bw := &blockingWriter{release: make(chan struct{})}
+	w := grpc_zerolog.NewAsyncWriter(bw, 2)
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(bytes.NewBuffer(nil)), grpc_zerolog.WithAsyncEmission(w), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall))
+	// the first line is taken by the blocked goroutine or stays in the buffer, so at most 3 lines are kept
+	for n := 0; n < 10; n++ {
+		callUnary(i, nil, nil)
+	}
+	if w.Dropped() < 7 {
+		t.Errorf("got %d dropped lines, want at least 7", w.Dropped())
+	}
+	close(bw.release)
+	_ = w.Close()
+
+	out := bw.b.String()
+	if got := strings.Count(out, "finished unary call"); got+int(w.Dropped()) != 10 {
+		t.Errorf("got %d written and %d dropped lines, want 10 in total", got, w.Dropped())
+	}
+	if !strings.Contains(out, "dropped log lines") {
+		t.Errorf("the dropped lines are not reported in %q", out)
+	}
+}
@@ -0,0 +1,73 @@
+package grpc_zerolog
+
+import (
+	"runtime/debug"
+
+	"github.com/rs/zerolog"
+)
+
+// WithBuildInfo adds the "service.version" (the main module version), "vcs.revision" and "vcs.modified" fields
+// read from the build info of the binary to every log line of the interceptor.
+// The build info is read once at the interceptor creation, the unknown fields are omitted.
+func WithBuildInfo() Option {
+	return func(o *options) {
+		o.buildInfo = true
+	}
+}
+
+// WithServiceName adds the "service.name" field to every log line of the interceptor
+func WithServiceName(name string) Option {
+	return func(o *options) {
+		o.serviceName = name
+	}
+}
+
+// WithServiceVersion adds the "service.version" field to every log line of the interceptor,
+// it overrides the version read by WithBuildInfo
+func WithServiceVersion(version string) Option {
+	return func(o *options) {
+		o.serviceVersion = version
+	}
+}
+
+type buildFields struct {
+	version  string
+	revision string
+	modified string
+}
+
+func readBuildFields() buildFields {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return buildFields{}
+	}
+	f := buildFields{}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		f.version = v
+	}
+	f.revision, f.modified = vcsSettings(info)
+	return f
+}
+
+func (o *options) withBuildFields(with zerolog.Context) zerolog.Context {
+	var f buildFields
+	if o.buildInfo {
+		f = readBuildFields()
+	}
+	if o.serviceVersion != "" {
+		f.version = o.serviceVersion
+	}
+	if o.serviceName != "" {
+		with = with.Str("service.name", o.serviceName)
+	}
+	if f.version != "" {
+		with = with.Str("service.version", f.version)
+	}
+	if f.revision != "" {
+		with = with.Str("vcs.revision", f.revision)
+	}
+	if f.modified != "" {
+		with = with.Str("vcs.modified", f.modified)
+	}
+	return with
+}
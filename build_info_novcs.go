@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package grpc_zerolog
+
+import "runtime/debug"
+
+// vcsSettings returns nothing, the VCS info is stamped into the binaries since Go 1.18
+func vcsSettings(info *debug.BuildInfo) (revision, modified string) {
+	return "", ""
+}
@@ -0,0 +1,18 @@
+//go:build go1.18
+// +build go1.18
+
+package grpc_zerolog
+
+import "runtime/debug"
+
+func vcsSettings(info *debug.BuildInfo) (revision, modified string) {
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	return revision, modified
+}
@@ -0,0 +1,72 @@
+package grpc_zerolog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// burstBuckets is the number of the buckets of the sliding window of WithBurstDetection
+const burstBuckets = 10
+
+// maxBurstMethods is the greatest number of the methods tracked by WithBurstDetection, the calls of the other methods
+// are never marked as burst
+const maxBurstMethods = 10000
+
+// WithBurstDetection adds grpc.burst: true to all events of the call that arrives when more than threshold calls
+// of its method (including this one) arrived during the last window. The rate is counted approximately
+// by the ring of 10 buckets per method. The interceptors created with the same returned Option share the counters.
+func WithBurstDetection(threshold int, window time.Duration) Option {
+	width := window / burstBuckets
+	if width <= 0 {
+		width = 1
+	}
+	d := &burstDetector{threshold: int64(threshold), width: int64(width)}
+	return func(o *options) {
+		o.burst = d
+	}
+}
+
+type burstDetector struct {
+	threshold int64
+	width     int64    // the duration of a bucket in nanoseconds
+	methods   sync.Map // full method name -> *methodRate
+	size      int64    // the number of the methods
+}
+
+// methodRate counts the calls of a method per bucket, epochs are the numbers of the buckets since the Unix epoch
+type methodRate struct {
+	mu     sync.Mutex
+	counts [burstBuckets]int64
+	epochs [burstBuckets]int64
+}
+
+// arrive counts the call of the method and reports whether the rate exceeds the threshold
+func (d *burstDetector) arrive(fullMethod string, now time.Time) bool {
+	v, ok := d.methods.Load(fullMethod)
+	if !ok {
+		if atomic.LoadInt64(&d.size) >= maxBurstMethods {
+			return false
+		}
+		if v, ok = d.methods.LoadOrStore(fullMethod, &methodRate{}); !ok {
+			atomic.AddInt64(&d.size, 1)
+		}
+	}
+	r := v.(*methodRate)
+
+	epoch := now.UnixNano() / d.width
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := epoch % burstBuckets
+	if r.epochs[i] != epoch {
+		r.epochs[i], r.counts[i] = epoch, 0
+	}
+	r.counts[i]++
+	var total int64
+	for j := range r.counts {
+		if epoch-r.epochs[j] < burstBuckets {
+			total += r.counts[j]
+		}
+	}
+	return total > d.threshold
+}
@@ -0,0 +1,19 @@
+package grpc_zerolog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// methodCounters holds a counter per gRPC method, the number of methods is finite so the map is bounded
+type methodCounters struct {
+	counters sync.Map // full method name -> *int64
+}
+
+func (c *methodCounters) add(fullMethod string, delta int64) int64 {
+	v, ok := c.counters.Load(fullMethod)
+	if !ok {
+		v, _ = c.counters.LoadOrStore(fullMethod, new(int64))
+	}
+	return atomic.AddInt64(v.(*int64), delta)
+}
@@ -0,0 +1,63 @@
+package grpc_zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/pereslava/grpc_zerolog/ctxzerolog"
+	"github.com/rs/zerolog"
+)
+
+// callField is the name and the encoded JSON value of a field of the call logger
+type callField struct {
+	key   string
+	value json.RawMessage
+}
+
+// callFieldsHook adds the fields of the call logger to the events
+type callFieldsHook []callField
+
+// CallFieldsHook returns the hook adding the fields of the call logger stored in ctx by the interceptors (see ctxzerolog)
+// to every event of the logger it is added to, e.g.
+//
+//	logger := appLogger.Hook(grpc_zerolog.CallFieldsHook(ctx))
+//
+// The fields are read when the hook is created, the later ctxzerolog.Set changes are not seen by it.
+// The hook does nothing if ctx has no call logger.
+func CallFieldsHook(ctx context.Context) zerolog.Hook {
+	buf := &bytes.Buffer{}
+	l := ctxzerolog.Get(ctx).Logger().Output(buf).Sample(nil)
+	l.Log().Msg("")
+	return callFieldsHook(decodeCallFields(buf.Bytes()))
+}
+
+// decodeCallFields returns the fields of the JSON log line in their order, the time is omitted
+func decodeCallFields(line []byte) []callField {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil
+	}
+	var fields []callField
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return fields
+		}
+		key, _ := t.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return fields
+		}
+		if key != zerolog.TimestampFieldName {
+			fields = append(fields, callField{key: key, value: value})
+		}
+	}
+	return fields
+}
+
+func (h callFieldsHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	for _, f := range h {
+		e.RawJSON(f.key, f.value)
+	}
+}
@@ -0,0 +1,27 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// WithCancelCauseField adds the cancellation cause of the call context as "grpc.cancel_cause" field of the FinishCall event.
+// The field is omitted if the context is not canceled or the cause is the same as the context error.
+// NOTE: the cause is available only for the binaries built with Go 1.20 or later.
+func WithCancelCauseField() Option {
+	return func(o *options) {
+		o.cancelCauseField = true
+	}
+}
+
+func (o *options) withCancelCause(ctx context.Context, with zerolog.Context) zerolog.Context {
+	err := ctx.Err()
+	if err == nil {
+		return with
+	}
+	if cause := contextCause(ctx); cause != nil && cause != err {
+		with = with.Str("grpc.cancel_cause", o.truncateValue(cause.Error()))
+	}
+	return with
+}
@@ -0,0 +1,11 @@
+//go:build !go1.20
+// +build !go1.20
+
+package grpc_zerolog
+
+import "context"
+
+// contextCause returns nothing, the contexts carry the cancellation cause since Go 1.20
+func contextCause(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package grpc_zerolog
+
+import (
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// WithCompressionDecisionField adds whether the client call compresses its requests as "grpc.request.compressed"
+// field of the FinishCall event of the client interceptors. It is read from the grpc.UseCompressor call options,
+// including the default call options of the connection, the last one wins and "identity" means no compression.
+// The field is false for the calls compressed by the deprecated grpc.WithCompressor dial option.
+func WithCompressionDecisionField() Option {
+	return func(o *options) {
+		o.compressionDecisionField = true
+	}
+}
+
+// recordCompressionDecision keeps whether the call options of the client call compress the requests
+func (o *options) recordCompressionDecision(ci *callInfo, opts []grpc.CallOption) {
+	if !o.compressionDecisionField {
+		return
+	}
+	compressed := false
+	for _, opt := range opts {
+		if c, ok := opt.(grpc.CompressorCallOption); ok {
+			compressed = c.CompressorType != "" && c.CompressorType != "identity"
+		}
+	}
+	ci.requestCompressed = &compressed
+}
+
+func withCompressionDecision(ci *callInfo, with zerolog.Context) zerolog.Context {
+	return with.Bool("grpc.request.compressed", *ci.requestCompressed)
+}
@@ -0,0 +1,41 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// WithContextLevel sets the level of all events of the call to the level returned by f, e.g. to log a call verbosely
+// when the client sends a debug header. If the level is below the level of the logger, the logger of the call
+// (including the one stored in the handler context) is lowered to it, the global zerolog level still applies.
+// The calls with the forced level are not sampled out. When f returns false the levels are chosen as usual.
+func WithContextLevel(f func(ctx context.Context) (zerolog.Level, bool)) Option {
+	return func(o *options) {
+		o.contextLevel = f
+	}
+}
+
+// forceLevel applies the level of WithContextLevel to the call, it returns the logger of the call
+func (o *options) forceLevel(ctx context.Context, ci *callInfo, logger zerolog.Logger) zerolog.Logger {
+	if o.contextLevel == nil || ctx == nil {
+		return logger
+	}
+	level, ok := o.contextLevel(ctx)
+	if !ok {
+		return logger
+	}
+	ci.forcedLevel, ci.levelForced = level, true
+	if level < logger.GetLevel() {
+		logger = logger.Level(level)
+	}
+	return logger
+}
+
+// eventLevel returns the level forced by WithContextLevel if any, otherwise level
+func (ci *callInfo) eventLevel(level zerolog.Level) zerolog.Level {
+	if ci.levelForced {
+		return ci.forcedLevel
+	}
+	return level
+}
@@ -0,0 +1,43 @@
+package grpc_zerolog
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+)
+
+// CostFunction returns the cost of the request message req of the method, e.g. the compute units to bill
+type CostFunction func(fullMethod string, req proto.Message) float64
+
+// WithCostFunction adds the cost of the call computed by f as "grpc.cost" field of the FinishCall event.
+// The cost of the stream is the sum of the costs of its request messages, i.e. the messages received by the server
+// or sent by the client, f is called once per message. The non-proto messages cost nothing.
+func WithCostFunction(f CostFunction) Option {
+	return func(o *options) {
+		o.costFunc = f
+	}
+}
+
+// addCost adds the cost of the message m to the cost of the call if it is a request
+func (o *options) addCost(ci *callInfo, sent bool, m interface{}) {
+	if o.costFunc == nil || sent == ci.server {
+		return
+	}
+	p, ok := m.(proto.Message)
+	if !ok {
+		return
+	}
+	cost := o.costFunc(ci.fullMethod, p)
+	for {
+		old := atomic.LoadUint64(&ci.cost)
+		if atomic.CompareAndSwapUint64(&ci.cost, old, math.Float64bits(math.Float64frombits(old)+cost)) {
+			return
+		}
+	}
+}
+
+func withCost(ci *callInfo, with zerolog.Context) zerolog.Context {
+	return with.Float64("grpc.cost", math.Float64frombits(atomic.LoadUint64(&ci.cost)))
+}
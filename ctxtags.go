@@ -0,0 +1,119 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog"
+)
+
+// TagsExtractor function returns the tags stored in the call context
+type TagsExtractor func(ctx context.Context) map[string]interface{}
+
+// WithCtxTags adds the tags stored in the call context to every event as "grpc.tags.<key>" fields.
+// It is the interop with grpc_ctxtags of go-grpc-middleware without the dependency on it, e.g.
+//
+//	grpc_zerolog.WithCtxTags(func(ctx context.Context) map[string]interface{} {
+//		return grpc_ctxtags.Extract(ctx).Values()
+//	})
+//
+// The tags are read when the call starts, so the interceptor must be chained after the one setting the tags.
+// The nil maps are ignored.
+func WithCtxTags(extract TagsExtractor) Option {
+	return func(o *options) {
+		o.tagsExtractor = extract
+	}
+}
+
+// ContextValueSpec describes the value of the call context logged by WithContextValueFields
+type ContextValueSpec struct {
+	Key       interface{}                // the key of the value in the context
+	Field     string                     // the name of the logged field
+	Stringify func(v interface{}) string // formats the value, nil means fmt.Sprint
+}
+
+// WithContextValueFields adds the values stored in the call context under the keys of the specs to every event, e.g.
+//
+//	grpc_zerolog.WithContextValueFields(grpc_zerolog.ContextValueSpec{Key: cohortKey{}, Field: "app.cohort"})
+//
+// The values are read when the call starts, so the interceptor must be chained after the one setting them.
+// The fields of the absent keys are omitted. The option may be given more than once, the specs are added up.
+func WithContextValueFields(specs ...ContextValueSpec) Option {
+	return func(o *options) {
+		o.contextValueSpecs = append(o.contextValueSpecs, specs...)
+	}
+}
+
+func (o *options) withContextValues(ctx context.Context, with zerolog.Context) zerolog.Context {
+	for _, s := range o.contextValueSpecs {
+		v := ctx.Value(s.Key)
+		if v == nil {
+			continue
+		}
+		var str string
+		if s.Stringify != nil {
+			str = s.Stringify(v)
+		} else {
+			str = fmt.Sprint(v)
+		}
+		with = with.Str(s.Field, o.truncateValue(str))
+	}
+	return with
+}
+
+// WithCacheHitField adds grpc.cache_hit to the FinishCall event with the value stored in the call context under ctxKey
+// by the caching interceptor, e.g. to tell the responses served from the cache. The value is a bool, or a *bool
+// so the interceptor chained after this one can set it once the result is known. The field is omitted if the key is absent.
+func WithCacheHitField(ctxKey interface{}) Option {
+	return func(o *options) {
+		o.cacheHitKey = ctxKey
+	}
+}
+
+func (o *options) withCacheHit(ctx context.Context, with zerolog.Context) zerolog.Context {
+	switch v := ctx.Value(o.cacheHitKey).(type) {
+	case bool:
+		return with.Bool("grpc.cache_hit", v)
+	case *bool:
+		if v != nil {
+			return with.Bool("grpc.cache_hit", *v)
+		}
+	}
+	return with
+}
+
+// WithSortedFields adds the fields taken from the maps (e.g. the tags of WithCtxTags) in the order of their keys,
+// so the output is deterministic, e.g. for golden-file tests. It is off by default as sorting allocates and costs
+// O(n log n) per call, the map fields of LogMessage are always sorted by zerolog.
+func WithSortedFields() Option {
+	return func(o *options) {
+		o.sortedFields = true
+	}
+}
+
+func (o *options) withCtxTags(ctx context.Context, with zerolog.Context) zerolog.Context {
+	tags := o.tagsExtractor(ctx)
+	if !o.sortedFields {
+		for k, v := range tags {
+			with = o.withTag(with, k, v)
+		}
+		return with
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		with = o.withTag(with, k, tags[k])
+	}
+	return with
+}
+
+func (o *options) withTag(with zerolog.Context, k string, v interface{}) zerolog.Context {
+	if s, ok := v.(string); ok {
+		return with.Str("grpc.tags."+k, o.truncateValue(s))
+	}
+	return with.Interface("grpc.tags."+k, v)
+}
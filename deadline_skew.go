@@ -0,0 +1,62 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type inboundDeadlineKey struct{}
+
+// WithDeadlineSkewDetection makes the client interceptors log "grpc.deadline_skew_ms" if the deadline of the outgoing call
+// is tighter than the deadline of the inbound call by more than margin, e.g. because of skewed clocks or aggressive proxies.
+// The inbound deadline is stored into the handler context by the server interceptors with this option,
+// the field is omitted if there is no inbound deadline.
+func WithDeadlineSkewDetection(margin time.Duration) Option {
+	return func(o *options) {
+		o.deadlineSkew = true
+		o.deadlineSkewMargin = margin
+	}
+}
+
+// withInboundDeadline stores the deadline of the server call into the handler context for WithDeadlineSkewDetection
+func (o *options) withInboundDeadline(ctx context.Context, ci *callInfo) context.Context {
+	if !o.deadlineSkew || ci.deadline.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, inboundDeadlineKey{}, ci.deadline)
+}
+
+// withDeadlineSkew adds the difference of the inbound and the outgoing deadline if it exceeds the margin
+func (o *options) withDeadlineSkew(ctx context.Context, with zerolog.Context) zerolog.Context {
+	inbound, ok := ctx.Value(inboundDeadlineKey{}).(time.Time)
+	if !ok {
+		return with
+	}
+	outgoing, ok := ctx.Deadline()
+	if !ok {
+		return with
+	}
+	if skew := inbound.Sub(outgoing); skew > o.deadlineSkewMargin {
+		with = with.Dur("grpc.deadline_skew_ms", skew)
+	}
+	return with
+}
+
+// WithDeadlineLogging makes the client interceptors add the deadline of the outgoing call as "grpc.client.deadline"
+// and the time left until it as "grpc.client.timeout_ms" fields of every event, starting with StartCall.
+// The calls without deadline have "grpc.client.deadline": "none".
+func WithDeadlineLogging() Option {
+	return func(o *options) {
+		o.deadlineLogging = true
+	}
+}
+
+// withClientDeadline adds the deadline of the outgoing call for WithDeadlineLogging
+func withClientDeadline(ci *callInfo, with zerolog.Context) zerolog.Context {
+	if ci.deadline.IsZero() {
+		return with.Str("grpc.client.deadline", "none")
+	}
+	return with.Time("grpc.client.deadline", ci.deadline).Dur("grpc.client.timeout_ms", ci.deadline.Sub(ci.start))
+}
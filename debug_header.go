@@ -0,0 +1,47 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+// WithDebugHeader enables the payload events for the single server calls carrying the given metadata header,
+// e.g. to capture the payloads of a reproduced request in production. If requiredValue is not empty the header
+// value must be equal to it, the comparison is constant-time. The events are logged at Debug, see WithDebugHeaderLevel.
+// They bypass the configured events and the payload sampling but the field masks and the size limits still apply.
+// The calls without the header are logged as configured.
+//
+// NOTE: without requiredValue any client able to set metadata can make the service log its payloads,
+// use a shared secret and strip the header at the edge for the public endpoints. Keep in mind the secret
+// travels in the metadata and may be logged by proxies.
+func WithDebugHeader(header string, requiredValue string) Option {
+	return func(o *options) {
+		o.debugHeader = strings.ToLower(header)
+		o.debugHeaderValue = requiredValue
+	}
+}
+
+// WithDebugHeaderLevel sets the level of the payload events enabled by WithDebugHeader, Debug by default
+func WithDebugHeaderLevel(level zerolog.Level) Option {
+	return func(o *options) {
+		o.debugHeaderLevel = level
+	}
+}
+
+// hasDebugHeader reports whether the incoming metadata carries the header set by WithDebugHeader
+func (o *options) hasDebugHeader(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, v := range md.Get(o.debugHeader) {
+		if o.debugHeaderValue == "" || subtle.ConstantTimeCompare([]byte(v), []byte(o.debugHeaderValue)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+type degradedKey struct{}
+
+// degradation is the mark of the degraded response set by MarkDegraded
+type degradation struct {
+	mu     sync.Mutex
+	marked bool
+	reason string
+}
+
+// MarkDegraded marks the response of the server call of ctx as degraded, e.g. a partial result returned with codes.OK.
+// The FinishCall event of the call has "grpc.degraded": true and the reason as "grpc.degraded.reason" field,
+// the reason of the last mark is logged. It does nothing if ctx is not the context of a call of the server interceptors.
+func MarkDegraded(ctx context.Context, reason string) {
+	d, ok := ctx.Value(degradedKey{}).(*degradation)
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	d.marked, d.reason = true, reason
+	d.mu.Unlock()
+}
+
+// withDegradation stores the degradation mark of the server call into the handler context
+func withDegradation(ctx context.Context, ci *callInfo) context.Context {
+	ci.degraded = &degradation{}
+	return context.WithValue(ctx, degradedKey{}, ci.degraded)
+}
+
+func (o *options) withDegraded(ci *callInfo, with zerolog.Context) zerolog.Context {
+	d := ci.degraded
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.marked {
+		return with
+	}
+	with = with.Bool("grpc.degraded", true)
+	if d.reason != "" {
+		with = with.Str("grpc.degraded.reason", o.truncateValue(d.reason))
+	}
+	return with
+}
@@ -0,0 +1,51 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+// WithMetadataDerivedDeadline makes the server interceptors shorten the deadline of the handler context to the timeout
+// given by the client in the header of the incoming metadata (e.g. "x-soft-timeout"), parsed by time.ParseDuration, e.g. "250ms".
+// The timeout applies only if it is positive and ends before the deadline of the call, it is logged as
+// "grpc.applied_timeout_ms" field of the FinishCall event. The context is left as is if the header is absent or malformed.
+func WithMetadataDerivedDeadline(header string) Option {
+	return func(o *options) {
+		o.derivedDeadlineHeader = strings.ToLower(header)
+	}
+}
+
+// withDerivedDeadline applies the timeout of WithMetadataDerivedDeadline to the handler context of the server call,
+// the returned function must be called when the handler returns
+func (o *options) withDerivedDeadline(ctx context.Context, ci *callInfo) (context.Context, context.CancelFunc) {
+	noop := func() {}
+	if o.derivedDeadlineHeader == "" {
+		return ctx, noop
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, noop
+	}
+	v := md.Get(o.derivedDeadlineHeader)
+	if len(v) == 0 {
+		return ctx, noop
+	}
+	timeout, err := time.ParseDuration(strings.TrimSpace(v[0]))
+	if err != nil || timeout <= 0 {
+		return ctx, noop
+	}
+	deadline := time.Now().Add(timeout)
+	if !ci.deadline.IsZero() && !deadline.Before(ci.deadline) {
+		return ctx, noop
+	}
+	ci.deadline, ci.appliedTimeout = deadline, timeout
+	return context.WithDeadline(ctx, deadline)
+}
+
+func withAppliedTimeout(ci *callInfo, with zerolog.Context) zerolog.Context {
+	return with.Dur("grpc.applied_timeout_ms", ci.appliedTimeout)
+}
@@ -0,0 +1,35 @@
+package grpc_zerolog
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	// DefaultDurationToField is the default implementation of the duration field,
+	// logs the duration as "grpc.time_ms" using the zerolog.DurationFieldUnit and zerolog.DurationFieldInteger settings
+	DefaultDurationToField DurationToField = func(with zerolog.Context, duration time.Duration) zerolog.Context {
+		return with.Dur("grpc.time_ms", duration)
+	}
+
+	// DurationToTimeMillisFields logs the duration as "grpc.time_ms" float32 milliseconds with microsecond precision,
+	// the same as grpc_zap.DurationToTimeMillisField does
+	DurationToTimeMillisFields DurationToField = func(with zerolog.Context, duration time.Duration) zerolog.Context {
+		return with.Float32("grpc.time_ms", float32(duration.Nanoseconds()/1000)/1000)
+	}
+
+	// DurationToDurationField logs the duration as "grpc.duration" string (e.g. "1.5ms"),
+	// the same as grpc_zap.DurationToDurationField does with the string duration encoder
+	DurationToDurationField DurationToField = func(with zerolog.Context, duration time.Duration) zerolog.Context {
+		return with.Str("grpc.duration", duration.String())
+	}
+
+	// DurationToSecondsFields logs the duration as "grpc.time_s" float64 seconds
+	DurationToSecondsFields DurationToField = func(with zerolog.Context, duration time.Duration) zerolog.Context {
+		return with.Float64("grpc.time_s", duration.Seconds())
+	}
+)
+
+// DurationToField function defines how the duration of the call is added to the log line
+type DurationToField func(with zerolog.Context, duration time.Duration) zerolog.Context
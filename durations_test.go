@@ -0,0 +1,33 @@
+package grpc_zerolog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pereslava/grpc_zerolog"
+	"github.com/rs/zerolog"
+)
+
+func TestDurationToFieldFormatting(t *testing.T) {
+	const duration = 1234567 * time.Nanosecond
+	tests := []struct {
+		name string
+		f    grpc_zerolog.DurationToField
+		want string
+	}{
+		{"DurationToTimeMillisFields", grpc_zerolog.DurationToTimeMillisFields, `{"grpc.time_ms":1.234}` + "\n"},
+		{"DurationToDurationField", grpc_zerolog.DurationToDurationField, `{"grpc.duration":"1.234567ms"}` + "\n"},
+		{"DurationToSecondsFields", grpc_zerolog.DurationToSecondsFields, `{"grpc.time_s":0.001234567}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			l := tt.f(zerolog.New(b).With(), duration).Logger()
+			l.Log().Send()
+			if got := b.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
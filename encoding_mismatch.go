@@ -0,0 +1,67 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/stats"
+)
+
+const msgEncodingMismatch message = "request encoding not supported"
+
+// WithEncodingMismatchDetection logs the calls compressed by the client with an encoding (the "grpc-encoding" header)
+// not registered on the server. grpc-go rejects such calls with codes.Unimplemented before the interceptors,
+// so they are logged by the stats handler returned by NewServerStatsHandler as a separate Warn line with
+// "grpc.encoding.mismatch": true, the requested encoding as "grpc.encoding.requested" and the registered ones
+// checked as "grpc.encoding.supported". ServerOptions registers the stats handler if the option is given,
+// it replaces the stats handler set by grpc.StatsHandler, the last one wins.
+func WithEncodingMismatchDetection() Option {
+	return func(o *options) {
+		o.encodingMismatch = true
+	}
+}
+
+// NewServerStatsHandler returns the stats handler logging the calls detected by WithEncodingMismatchDetection,
+// it must be registered on the server by grpc.StatsHandler unless ServerOptions is used
+func NewServerStatsHandler(logger zerolog.Logger, opts ...Option) stats.Handler {
+	o := evaluateOptions(opts)
+	return serverStatsHandler{o: o, logger: o.baseLogger(logger)}
+}
+
+type serverStatsHandler struct {
+	o      *options
+	logger zerolog.Logger
+}
+
+// knownEncodings are the encodings checked for the registered compressors
+var knownEncodings = []string{"gzip", "deflate", "snappy", "zstd", "br", "lz4"}
+
+func (h serverStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h serverStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	in, ok := s.(*stats.InHeader)
+	if !ok || !h.o.encodingMismatch || in.Compression == "" || in.Compression == "identity" {
+		return
+	}
+	if encoding.GetCompressor(in.Compression) != nil {
+		return
+	}
+	supported := []string{}
+	for _, name := range knownEncodings {
+		if encoding.GetCompressor(name) != nil {
+			supported = append(supported, name)
+		}
+	}
+	l := initLog(ctx, h.logger, in.FullMethod).Logger()
+	l.Warn().Bool("grpc.encoding.mismatch", true).Str("grpc.encoding.requested", in.Compression).
+		Strs("grpc.encoding.supported", supported).Msg(string(msgEncodingMismatch))
+}
+
+func (h serverStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h serverStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,147 @@
+package grpc_zerolog
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables read by OptionsFromEnv if the prefix is empty
+const DefaultEnvPrefix = "GRPC_ZEROLOG"
+
+// envEvents are the names of the loggable events in the environment variables
+var envEvents = map[string]LoggableEvent{
+	"start":                  StartCall,
+	"finish":                 FinishCall,
+	"payload_received":       PayloadReceived,
+	"payload_sent":           PayloadSent,
+	"stream_opened":          StreamOpened,
+	"payload_send_failed":    PayloadSendFailed,
+	"payload_receive_failed": PayloadReceiveFailed,
+}
+
+// OptionsFromEnv returns the options set by the environment variables with the given prefix
+// (DefaultEnvPrefix if empty), so the verbosity can be changed without rebuilding the binary:
+//
+//	<prefix>_EVENTS=start,finish,payload_received    WithLogOnEvents, empty value disables all events
+//	<prefix>_CODE_LEVELS=NotFound=debug,Internal=error  WithCodeLevels
+//	<prefix>_SKIP_METHODS=/grpc.health.v1.Health/*   WithSkipMethods by MatchPattern
+//	<prefix>_MAX_PAYLOAD=4096                        WithMaxPayloadSize
+//
+// The unset variables contribute nothing, so the result can be appended to the options given in code.
+// The error names the variable with the invalid value.
+func OptionsFromEnv(prefix string) ([]Option, error) {
+	return optionsFromLookup(prefix, os.LookupEnv)
+}
+
+func optionsFromLookup(prefix string, lookup func(string) (string, bool)) ([]Option, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+
+	var opts []Option
+	parsers := []struct {
+		name  string
+		parse func(string) (Option, error)
+	}{
+		{"EVENTS", parseEnvEvents},
+		{"CODE_LEVELS", parseEnvCodeLevels},
+		{"SKIP_METHODS", parseEnvSkipMethods},
+		{"MAX_PAYLOAD", parseEnvMaxPayload},
+	}
+	for _, p := range parsers {
+		v, ok := lookup(prefix + p.name)
+		if !ok {
+			continue
+		}
+		opt, err := p.parse(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("grpc_zerolog: invalid %s=%q: %v", prefix+p.name, v, err)
+		}
+		opts = append(opts, opt)
+	}
+	return opts, nil
+}
+
+// splitEnvList splits the comma separated list skipping the empty items
+func splitEnvList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseEnvEvents(v string) (Option, error) {
+	var events []LoggableEvent
+	for _, name := range splitEnvList(v) {
+		e, ok := envEvents[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown event %q", name)
+		}
+		events = append(events, e)
+	}
+	return WithLogOnEvents(events...), nil
+}
+
+// parseEnvCode parses the code by its Go name (e.g. "NotFound") or canonical name (e.g. "NOT_FOUND"), case insensitive
+func parseEnvCode(name string) (codes.Code, bool) {
+	for code, canonical := range codeNames {
+		if strings.EqualFold(name, code.String()) || strings.EqualFold(name, canonical) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+func parseEnvCodeLevels(v string) (Option, error) {
+	levels := map[codes.Code]zerolog.Level{}
+	for _, item := range splitEnvList(v) {
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not in the code=level form", item)
+		}
+		name, levelName := strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+1:])
+		code, ok := parseEnvCode(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown code %q", name)
+		}
+		level, err := zerolog.ParseLevel(strings.ToLower(levelName))
+		if err != nil || levelName == "" {
+			return nil, fmt.Errorf("unknown level %q of code %s", levelName, name)
+		}
+		levels[code] = level
+	}
+	return WithCodeLevels(levels), nil
+}
+
+func parseEnvSkipMethods(v string) (Option, error) {
+	var matchers []Matcher
+	for _, pattern := range splitEnvList(v) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %q", pattern)
+		}
+		matchers = append(matchers, MatchPattern(pattern))
+	}
+	return func(o *options) {
+		for _, m := range matchers {
+			WithSkipMethods(m)(o)
+		}
+	}, nil
+}
+
+func parseEnvMaxPayload(v string) (Option, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%q is not a non-negative number of bytes", v)
+	}
+	return WithMaxPayloadSize(n), nil
+}
@@ -0,0 +1,123 @@
+package grpc_zerolog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func evaluateEnv(t *testing.T, prefix string, env map[string]string) *options {
+	t.Helper()
+	opts, err := optionsFromLookup(prefix, envLookup(env))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return evaluateOptions(opts)
+}
+
+func TestOptionsFromEnvUnset(t *testing.T) {
+	opts, err := optionsFromLookup("", envLookup(nil))
+	if err != nil || len(opts) != 0 {
+		t.Fatalf("got %d options, %v; want none", len(opts), err)
+	}
+}
+
+func TestOptionsFromEnvPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "GRPC_ZEROLOG", "GRPC_ZEROLOG_"} {
+		o := evaluateEnv(t, prefix, map[string]string{"GRPC_ZEROLOG_EVENTS": "finish"})
+		if !reflect.DeepEqual(o.loggableEvents, []LoggableEvent{FinishCall}) {
+			t.Errorf("prefix %q: got events %v", prefix, o.loggableEvents)
+		}
+	}
+	o := evaluateEnv(t, "MYAPP", map[string]string{"GRPC_ZEROLOG_EVENTS": "finish", "MYAPP_EVENTS": "start"})
+	if !reflect.DeepEqual(o.loggableEvents, []LoggableEvent{StartCall}) {
+		t.Errorf("custom prefix: got events %v", o.loggableEvents)
+	}
+}
+
+func TestOptionsFromEnvEvents(t *testing.T) {
+	for value, want := range map[string][]LoggableEvent{
+		"start,finish,payload_received":  {StartCall, FinishCall, PayloadReceived},
+		" payload_sent , STREAM_OPENED ": {PayloadSent, StreamOpened},
+		"finish,":                        {FinishCall},
+		"":                               nil,
+	} {
+		o := evaluateEnv(t, "", map[string]string{"GRPC_ZEROLOG_EVENTS": value})
+		if len(o.loggableEvents) != len(want) || (len(want) > 0 && !reflect.DeepEqual(o.loggableEvents, want)) {
+			t.Errorf("%q: got events %v, want %v", value, o.loggableEvents, want)
+		}
+	}
+}
+
+func TestOptionsFromEnvCodeLevels(t *testing.T) {
+	o := evaluateEnv(t, "", map[string]string{"GRPC_ZEROLOG_CODE_LEVELS": "NotFound=debug, INVALID_ARGUMENT = Warn,ok=trace,"})
+	for code, want := range map[codes.Code]zerolog.Level{
+		codes.NotFound:        zerolog.DebugLevel,
+		codes.InvalidArgument: zerolog.WarnLevel,
+		codes.OK:              zerolog.TraceLevel,
+		codes.Internal:        zerolog.ErrorLevel,
+	} {
+		if got := o.levelFunc(code); got != want {
+			t.Errorf("level of %s = %s, want %s", code, got, want)
+		}
+	}
+}
+
+func TestOptionsFromEnvSkipMethods(t *testing.T) {
+	o := evaluateEnv(t, "", map[string]string{"GRPC_ZEROLOG_SKIP_METHODS": "/grpc.health.v1.Health/*,/a.B/C"})
+	for method, want := range map[string]bool{
+		"/grpc.health.v1.Health/Check": false,
+		"/grpc.health.v1.Health/Watch": false,
+		"/a.B/C":                       false,
+		"/a.B/D":                       true,
+	} {
+		if got := o.decide(nil, method, nil); got != want {
+			t.Errorf("decide(%s) = %t, want %t", method, got, want)
+		}
+	}
+}
+
+func TestOptionsFromEnvMaxPayload(t *testing.T) {
+	o := evaluateEnv(t, "", map[string]string{"GRPC_ZEROLOG_MAX_PAYLOAD": " 4096 "})
+	if o.maxPayloadSize != 4096 {
+		t.Errorf("got max payload %d, want 4096", o.maxPayloadSize)
+	}
+}
+
+func TestOptionsFromEnvInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name, value string
+	}{
+		{"GRPC_ZEROLOG_EVENTS", "start,unknown"},
+		{"GRPC_ZEROLOG_CODE_LEVELS", "NotFound"},
+		{"GRPC_ZEROLOG_CODE_LEVELS", "NoSuchCode=debug"},
+		{"GRPC_ZEROLOG_CODE_LEVELS", "NotFound=verbose"},
+		{"GRPC_ZEROLOG_CODE_LEVELS", "NotFound="},
+		{"GRPC_ZEROLOG_SKIP_METHODS", "/a.B/[C"},
+		{"GRPC_ZEROLOG_MAX_PAYLOAD", "4k"},
+		{"GRPC_ZEROLOG_MAX_PAYLOAD", "-1"},
+		{"GRPC_ZEROLOG_MAX_PAYLOAD", ""},
+	} {
+		opts, err := optionsFromLookup("", envLookup(map[string]string{
+			"GRPC_ZEROLOG_EVENTS": "finish",
+			tc.name:               tc.value,
+		}))
+		if err == nil {
+			t.Errorf("%s=%q: got %d options, want error", tc.name, tc.value, len(opts))
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.name) {
+			t.Errorf("%s=%q: error %q does not name the variable", tc.name, tc.value, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package grpc_zerolog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+const msgRepeatedErrors = "repeated call errors"
+
+// maxDedupKeys is the greatest number of the errors deduplicated at once, the other errors are logged as usual
+const maxDedupKeys = 10000
+
+// WithErrorDeduplication logs the first FinishCall line of the failed call per method, code and error message,
+// and suppresses the same errors during the window. When the window closes one more line is logged
+// with the number of the suppressed errors in grpc.error.repeat_count (nothing if there were none).
+// The interceptors created with the same returned Option share the state.
+func WithErrorDeduplication(window time.Duration) Option {
+	d := &errorDeduper{window: window, errors: make(map[dedupKey]*dedupEntry)}
+	return func(o *options) {
+		o.errorDedup = d
+	}
+}
+
+type dedupKey struct {
+	fullMethod string
+	code       codes.Code
+	message    string
+}
+
+type dedupEntry struct {
+	repeats int64
+	level   zerolog.Level
+	log     zerolog.Context // the call logger of the first error with its code and error fields
+}
+
+type errorDeduper struct {
+	window time.Duration
+	mu     sync.Mutex
+	errors map[dedupKey]*dedupEntry
+}
+
+// suppress reports whether the error is the repeat of the error logged during the window, it counts the repeat.
+// Otherwise it starts the window of the error, the log function returns the fields of the repeat line.
+func (d *errorDeduper) suppress(ci *callInfo, code codes.Code, callError error, level zerolog.Level,
+	log func() zerolog.Context) bool {
+	key := dedupKey{fullMethod: ci.fullMethod, code: code, message: callError.Error()}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if e, ok := d.errors[key]; ok {
+		e.repeats++
+		return true
+	}
+	if len(d.errors) >= maxDedupKeys {
+		return false
+	}
+	d.errors[key] = &dedupEntry{level: level, log: log()}
+	time.AfterFunc(d.window, func() {
+		d.flush(key)
+	})
+	return false
+}
+
+// flush closes the window of the error and logs its repeats
+func (d *errorDeduper) flush(key dedupKey) {
+	d.mu.Lock()
+	e := d.errors[key]
+	delete(d.errors, key)
+	d.mu.Unlock()
+	if e == nil || e.repeats == 0 {
+		return
+	}
+	l := e.log.Int64("grpc.error.repeat_count", e.repeats).Dur("grpc.error.repeat_window_ms", d.window).Logger()
+	l.WithLevel(e.level).Msg(msgRepeatedErrors)
+}
+
+// repeatLog returns the fields of the repeat line of the error
+func (o *options) repeatLog(ci *callInfo, code codes.Code, callError error) zerolog.Context {
+	with := ci.eventLogContext(FinishCall).Str("grpc.code", o.codeName(code))
+	if o.hidesErrorMessages(code) {
+		return o.withPublicError(with, code, callError)
+	}
+	if !o.noErrorField {
+		with = o.withError(with, callError)
+	}
+	return with.Str("grpc.status_message", o.truncateValue(statusFromError(callError).Message()))
+}
@@ -0,0 +1,77 @@
+package grpc_zerolog
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+)
+
+// WithEventCallback calls f for every event the interceptors write with the fields of its log line, including
+// "level" and "message", e.g. to mirror the events into a metrics or tracing pipeline without parsing the logs.
+// It runs after the line is written, mutating the map has no effect on the line. The fields added by WithEventHook
+// and by the custom MessageProducer are not included. The line is rendered for f a second time, the payload
+// content is marshaled once and reused. The panics of f are recovered.
+func WithEventCallback(f func(event LoggableEvent, fields map[string]interface{})) Option {
+	return func(o *options) {
+		o.eventCallback = f
+	}
+}
+
+// renderPayloadFields renders the payload content like renderPayload and returns its fields, so the content
+// of the line is reused by the callback instead of marshaling the payload twice
+func (o *options) renderPayloadFields(prefix string, m interface{}) ([]callField, error) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(buf)
+	e, err := o.renderPayload(l.Log(), prefix, m)
+	if err != nil {
+		return nil, err
+	}
+	e.Msg("")
+	return decodeCallFields(buf.Bytes()), nil
+}
+
+// withRawFields adds the rendered fields to the event
+func withRawFields(e *zerolog.Event, fields []callField) *zerolog.Event {
+	for _, f := range fields {
+		e = e.RawJSON(f.key, f.value)
+	}
+	return e
+}
+
+// rendersEventFields reports whether the fields of the events of the call are passed to WithEventCallback or WithSpanEvents
+func (o *options) rendersEventFields(ci *callInfo) bool {
+	return o.eventCallback != nil || ci.span != nil
+}
+
+// runEventCallback renders the event with the logger context with and the fields added by add (nil if there are none)
+// and passes its fields to the callback of WithEventCallback and the span of WithSpanEvents
+func (o *options) runEventCallback(ci *callInfo, ev LoggableEvent, with zerolog.Context, level zerolog.Level, msg message,
+	add func(e *zerolog.Event) *zerolog.Event) {
+	if !o.rendersEventFields(ci) {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+	buf := &bytes.Buffer{}
+	l := with.Logger().Output(buf).Sample(nil)
+	e := l.WithLevel(level)
+	if e == nil {
+		return
+	}
+	if add != nil {
+		e = add(e)
+	}
+	e.Msg(string(msg))
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		return
+	}
+	if ci.span != nil {
+		ci.span.AddEvent(ev.String(), fields)
+	}
+	if o.eventCallback != nil {
+		o.eventCallback(ev, fields)
+	}
+}
@@ -0,0 +1,35 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+// EventHook function decorates the event of the call before it is written, e.g. to add the fields no option covers.
+// The event already has all the fields added by the interceptor. The hook must not write the event,
+// calling Msg or Send on it is undefined.
+type EventHook func(ctx context.Context, ev LoggableEvent, fullMethod string, e *zerolog.Event)
+
+// WithEventHook calls f for every event the interceptors write. It is not called for the disabled events.
+// The panics of f are recovered and logged with the event as "grpc.event_hook_panic".
+// The FinishCall event is written by the MessageProducer, the hook is not called for it if WithMessageProducer is set.
+func WithEventHook(f EventHook) Option {
+	return func(o *options) {
+		o.eventHook = f
+	}
+}
+
+// runEventHook calls the hook set by WithEventHook for the event of the call
+func (o *options) runEventHook(ci *callInfo, ev LoggableEvent, e *zerolog.Event) {
+	if o.eventHook == nil || e == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e.Str("grpc.event_hook_panic", fmt.Sprint(r))
+		}
+	}()
+	o.eventHook(ci.ctx, ev, ci.fullMethod, e)
+}
@@ -0,0 +1,86 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// WithEventLoggers makes the interceptors emit the events through the loggers given for them, e.g. the payload events
+// to a debug-only sink. The events without a logger use the logger given to the constructor, the payload events
+// the one of WithPayloadLogger if it is set. The events still carry the same call fields and their levels are
+// computed as usual, so each logger filters them by its own level.
+func WithEventLoggers(m map[LoggableEvent]*zerolog.Logger) Option {
+	return func(o *options) {
+		if o.eventLoggers == nil {
+			o.eventLoggers = make(map[LoggableEvent]*zerolog.Logger, len(m))
+		}
+		for e, l := range m {
+			o.eventLoggers[e] = l
+		}
+	}
+}
+
+// baseEventLoggers applies baseLogger to the loggers of WithEventLoggers once when the options are evaluated,
+// the events sharing a logger keep sharing it
+func (o *options) baseEventLoggers() {
+	if len(o.eventLoggers) == 0 {
+		return
+	}
+	based := make(map[*zerolog.Logger]*zerolog.Logger, len(o.eventLoggers))
+	loggers := make(map[LoggableEvent]*zerolog.Logger, len(o.eventLoggers))
+	for e, l := range o.eventLoggers {
+		if l == nil {
+			continue
+		}
+		b, ok := based[l]
+		if !ok {
+			bl := o.baseLogger(*l)
+			b = &bl
+			based[l] = b
+		}
+		loggers[e] = b
+	}
+	o.eventLoggers = loggers
+}
+
+// initEventLogs prepares the logger contexts of the events routed by WithEventLoggers, once per logger
+func (o *options) initEventLogs(ctx context.Context, ci *callInfo) {
+	if len(o.eventLoggers) == 0 {
+		return
+	}
+	ci.eventLogs = make(map[LoggableEvent]zerolog.Context, len(o.eventLoggers))
+	byLogger := make(map[*zerolog.Logger]zerolog.Context, len(o.eventLoggers))
+	for e, l := range o.eventLoggers {
+		if l == nil || (isPayloadEvent(e) && !ci.payloadSampled) {
+			continue
+		}
+		with, ok := byLogger[l]
+		if !ok {
+			with = o.initLog(ctx, *l, ci.loggedMethod, ci)
+			byLogger[l] = with
+		}
+		ci.eventLogs[e] = with
+	}
+}
+
+func isPayloadEvent(e LoggableEvent) bool {
+	return e == PayloadReceived || e == PayloadSent
+}
+
+// eventLog returns the logger context of the event, the fields must be added to its copy returned by eventLogContext
+func (ci *callInfo) eventLog(e LoggableEvent) zerolog.Context {
+	if with, ok := ci.eventLogs[e]; ok {
+		return with
+	}
+	if isPayloadEvent(e) {
+		return ci.payloadLog
+	}
+	return ci.log
+}
+
+// eventLogContext returns the copy of the logger context of the event, see logContext
+func (ci *callInfo) eventLogContext(e LoggableEvent) zerolog.Context {
+	l := ci.eventLog(e).Logger()
+	return l.With()
+}
@@ -1,15 +1,20 @@
 package grpc_zerolog_test
 
 import (
+	"context"
 	"net"
 	"os"
 	"path"
+	"time"
 
 	"github.com/pereslava/grpc_zerolog"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func ExampleWithDecider() {
@@ -185,3 +190,56 @@ func Example_clientInitializationSimple() {
 	defer conn.Close()
 
 }
+
+func ExampleSelector() {
+	selector := grpc_zerolog.NewSelector(log.Logger).
+		MatchServices([]string{"mycompany.admin.v1.AdminService"},
+			grpc_zerolog.WithLevels(func(code codes.Code) zerolog.Level { return zerolog.DebugLevel }),
+		).
+		Match(grpc_zerolog.MatchPattern("/grpc.health.v1.Health/*"),
+			grpc_zerolog.WithLogOnEvents(),
+		).
+		Default(
+			grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+		)
+
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(selector.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(selector.StreamServerInterceptor()),
+	)
+}
+
+func ExampleServerOptions() {
+	// omit the duration to make the output stable
+	noDuration := func(with zerolog.Context, _ time.Duration) zerolog.Context { return with }
+	logger := zerolog.New(os.Stdout)
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(grpc_zerolog.ServerOptions(
+		logger.With().Str("side", "server").Logger(),
+		grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+		grpc_zerolog.WithDurationField(noDuration),
+	)...)
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	defer s.Stop()
+
+	dialOpts := append(grpc_zerolog.DialOptions(
+		logger.With().Str("side", "client").Logger(),
+		grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+		grpc_zerolog.WithDurationField(noDuration),
+	),
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+	)
+	conn, err := grpc.Dial("bufnet", dialOpts...)
+	if err != nil {
+		log.Fatal().Err(err).Send()
+	}
+	defer conn.Close()
+
+	_, _ = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	// Output:
+	// {"level":"info","side":"server","grpc.service":"grpc.health.v1.Health","grpc.method":"Check","grpc.code":"OK","message":"finished unary call"}
+	// {"level":"info","side":"client","grpc.service":"grpc.health.v1.Health","grpc.method":"Check","grpc.code":"OK","message":"finished unary call"}
+}
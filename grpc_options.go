@@ -0,0 +1,34 @@
+package grpc_zerolog
+
+import (
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// ServerOptions returns the server options chaining the unary and stream server interceptors.
+// Both interceptors share the same evaluated options. The interceptors are added by grpc.ChainUnaryInterceptor
+// and grpc.ChainStreamInterceptor, so they compose with the other chained interceptors in the order of the server options.
+func ServerOptions(logger zerolog.Logger, opts ...Option) []grpc.ServerOption {
+	o := evaluateOptions(opts)
+	provider := o.fixedLogger(logger)
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(o.unaryServerInterceptor(provider)),
+		grpc.ChainStreamInterceptor(o.streamServerInterceptor(provider)),
+	}
+	if o.encodingMismatch {
+		serverOpts = append(serverOpts, grpc.StatsHandler(serverStatsHandler{o: o, logger: o.baseLogger(logger)}))
+	}
+	return serverOpts
+}
+
+// DialOptions returns the dial options chaining the unary and stream client interceptors.
+// Both interceptors share the same evaluated options. The interceptors are added by grpc.WithChainUnaryInterceptor
+// and grpc.WithChainStreamInterceptor, so they compose with the other chained interceptors in the order of the dial options.
+func DialOptions(logger zerolog.Logger, opts ...Option) []grpc.DialOption {
+	o := evaluateClientOptions(opts)
+	provider := o.fixedLogger(logger)
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(o.unaryClientInterceptor(provider)),
+		grpc.WithChainStreamInterceptor(o.streamClientInterceptor(provider)),
+	}
+}
@@ -0,0 +1,31 @@
+package grpc_zerolog
+
+import (
+	"path"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// handlerNames caches the names of the Go functions serving the gRPC methods
+type handlerNames struct {
+	names sync.Map // full method name -> string
+}
+
+// name returns the name of the srv method implementing the gRPC method, e.g. "main.(*orderServer).Get",
+// or empty string if it can't be resolved
+func (h *handlerNames) name(srv interface{}, fullMethod string) string {
+	if n, ok := h.names.Load(fullMethod); ok {
+		return n.(string)
+	}
+	n := ""
+	if srv != nil {
+		if m, ok := reflect.TypeOf(srv).MethodByName(path.Base(fullMethod)); ok {
+			if f := runtime.FuncForPC(m.Func.Pointer()); f != nil {
+				n = f.Name()
+			}
+		}
+	}
+	h.names.Store(fullMethod, n)
+	return n
+}
@@ -0,0 +1,102 @@
+package grpc_zerolog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	msgInFlight        message = "call still running"
+	msgStreamHeartbeat message = "stream heartbeat"
+)
+
+// WithInFlightLogging makes the interceptors log a heartbeat line for every interval the call is still running.
+// The line has the call fields, the elapsed time and the number of messages sent and received so far.
+// Nothing is logged for the calls finished within the first interval. Zero or negative interval disables it, it is the default.
+func WithInFlightLogging(interval time.Duration) Option {
+	return func(o *options) {
+		o.inFlightInterval = interval
+	}
+}
+
+// WithStreamHeartbeat is like WithInFlightLogging but for the stream calls only, the heartbeat lines have
+// "grpc.stream.heartbeat": true field. For the streams it takes precedence over the interval of WithInFlightLogging.
+// The heartbeat stops when the stream finishes. Zero or negative interval disables it, it is the default.
+func WithStreamHeartbeat(interval time.Duration) Option {
+	return func(o *options) {
+		o.streamHeartbeat = interval
+	}
+}
+
+// WithInFlightLevel sets the level of the heartbeat lines enabled by WithInFlightLogging, Info by default
+func WithInFlightLevel(level zerolog.Level) Option {
+	return func(o *options) {
+		o.inFlightLevel = level
+	}
+}
+
+// inFlightTimer emits the heartbeat lines of a call by a chain of time.AfterFunc
+type inFlightTimer struct {
+	mu        sync.Mutex
+	timer     *time.Timer
+	interval  time.Duration
+	heartbeat bool // the lines are the stream heartbeat of WithStreamHeartbeat
+	stopped   bool
+}
+
+// startInFlight starts the heartbeat of the call if it is enabled and the call is logged
+func (o *options) startInFlight(ci *callInfo) {
+	t := &inFlightTimer{interval: o.inFlightInterval}
+	if ci.stream && o.streamHeartbeat > 0 {
+		t.interval, t.heartbeat = o.streamHeartbeat, true
+	}
+	if t.interval <= 0 || !ci.sampled || !o.decide(ci.ctx, ci.fullMethod, nil) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timer = time.AfterFunc(t.interval, func() { o.logInFlight(ci, t) })
+	ci.inFlight = t
+}
+
+func (o *options) logInFlight(ci *callInfo, t *inFlightTimer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return
+	}
+	with := o.durationFunc(ci.logContext(), time.Since(ci.start).Round(o.durationRounding)).
+		Int64("grpc.messages.sent", atomic.LoadInt64(&ci.sentMessages)).
+		Int64("grpc.messages.received", atomic.LoadInt64(&ci.receivedMessages))
+	msg := msgInFlight
+	if t.heartbeat {
+		with, msg = with.Bool("grpc.stream.heartbeat", true), msgStreamHeartbeat
+	}
+	l := with.Logger()
+	l.WithLevel(ci.eventLevel(o.inFlightLevel)).Msg(string(msg))
+	t.timer.Reset(t.interval)
+}
+
+// stopInFlight stops the heartbeat of the call, it is safe to call it more than once
+func (ci *callInfo) stopInFlight() {
+	t := ci.inFlight
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.stopped = true
+	t.timer.Stop()
+	t.mu.Unlock()
+}
+
+// countMessage counts the message sent or received by the call for the heartbeat lines
+func (ci *callInfo) countMessage(sent bool) {
+	if sent {
+		atomic.AddInt64(&ci.sentMessages, 1)
+	} else {
+		atomic.AddInt64(&ci.receivedMessages, 1)
+	}
+}
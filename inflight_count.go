@@ -0,0 +1,70 @@
+package grpc_zerolog
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// inflightCounter counts the calls started but not finished yet
+type inflightCounter struct {
+	total   int64
+	methods *methodCounters // nil if the calls are not counted per method
+}
+
+// WithInflightCount adds the number of the calls in flight, including the logged one, as "grpc.inflight" field
+// of the StartCall and FinishCall events. The counter is created by the option, so it is shared by all the interceptors
+// created with the same Option value, e.g. the unary and stream server interceptors of ServerOptions.
+// The client streams are counted until they finish, see NewStreamClientInterceptor.
+func WithInflightCount() Option {
+	c := &inflightCounter{}
+	return func(o *options) {
+		o.inflightCount = c
+	}
+}
+
+// WithInflightCountPerMethod is like WithInflightCount and also adds the number of the calls of the same method
+// in flight as "grpc.inflight_method" field. There is a counter per method like for WithCallCountField.
+func WithInflightCountPerMethod() Option {
+	c := &inflightCounter{methods: &methodCounters{}}
+	return func(o *options) {
+		o.inflightCount = c
+	}
+}
+
+// enterCall counts the started call, exitCall must be called (with defer) once it finishes
+func (o *options) enterCall(ci *callInfo) {
+	c := o.inflightCount
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(&c.total, 1)
+	if c.methods != nil {
+		c.methods.add(ci.fullMethod, 1)
+	}
+	ci.inflightCounted = 1
+}
+
+// exitCall stops counting the call, it is safe to call it more than once
+func (o *options) exitCall(ci *callInfo) {
+	if !atomic.CompareAndSwapInt32(&ci.inflightCounted, 1, 0) {
+		return
+	}
+	c := o.inflightCount
+	atomic.AddInt64(&c.total, -1)
+	if c.methods != nil {
+		c.methods.add(ci.fullMethod, -1)
+	}
+}
+
+func (o *options) withInflightCount(ci *callInfo, with zerolog.Context) zerolog.Context {
+	c := o.inflightCount
+	if c == nil || atomic.LoadInt32(&ci.inflightCounted) == 0 {
+		return with
+	}
+	with = with.Int64("grpc.inflight", atomic.LoadInt64(&c.total))
+	if c.methods != nil {
+		with = with.Int64("grpc.inflight_method", c.methods.add(ci.fullMethod, 0))
+	}
+	return with
+}
@@ -2,34 +2,102 @@ package grpc_zerolog
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"path"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pereslava/grpc_zerolog/ctxzerolog"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
 const (
 	msgUnary        message = "finished unary call"
 	msgServerStream message = "finished stream call"
-	msgClientStream message = "started stream call"
+	msgClientStream message = "finished stream call"
+
+	msgStartUnary  message = "started unary call"
+	msgStartStream message = "started stream call"
+
+	msgStreamOpened   message = "received first stream message"
+	msgStreamRecvSize message = "received stream message"
+	msgSendFailed     message = "failed to send stream message"
+	msgReceiveFailed  message = "failed to receive stream message"
 )
 
 // NewUnaryServerInterceptor returns an unary server interceptor that adds zerolog to context and logs the gRPC calls
 func NewUnaryServerInterceptor(logger zerolog.Logger, opts ...Option) grpc.UnaryServerInterceptor {
 	o := evaluateOptions(opts)
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		start := time.Now()
-		l := initLog(ctx, logger, info.FullMethod)
+	return o.unaryServerInterceptor(o.fixedLogger(logger))
+}
 
-		res, err := handler(ctxzerolog.New(ctx, l.Logger()), req)
-		if !o.shouldLog(info.FullMethod, err) {
-			return res, err
+// UnaryServerInterceptorProvider returns an unary server interceptor like NewUnaryServerInterceptor,
+// but obtains the logger of each call from the provider
+func UnaryServerInterceptorProvider(p LoggerProvider, opts ...Option) grpc.UnaryServerInterceptor {
+	o := evaluateOptions(opts)
+	return o.unaryServerInterceptor(o.decorateProvider(p))
+}
+
+func (o *options) unaryServerInterceptor(provider LoggerProvider) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, err error) {
+		logger := provider(ctx, info.FullMethod)
+		if o.optedOut(logger) {
+			return handler(ctx, req)
+		}
+		ctx = o.onStartContext(ctx, info.FullMethod)
+		ci := o.newCall(ctx, logger, info.FullMethod, true)
+		ci.countsBytes = o.summaryCollector != nil
+		o.enterCall(ci)
+		defer o.exitCall(ci)
+		if o.handlerNames != nil {
+			ci.handler = o.handlerNames.name(info.Server, info.FullMethod)
 		}
-		doInterceptorLog(l, start, err, msgUnary, o.levelFunc)
+		o.retainCall(ci, 1)
+		if ci.requests != nil {
+			ci.requests.add(req)
+		}
+		if o.deferredEmission {
+			defer o.flushStartOnPanic(ci, msgStartUnary)
+		}
+		if o.recoverPanics {
+			defer o.recoverPanic(ci, &err, msgUnary)
+		}
+		o.logStart(ci, msgStartUnary)
+		ci.countMessage(false)
+		o.countBytes(ci, false, req)
+		o.recordMessageType(ci, false, req)
+		o.addCost(ci, false, req)
+		o.startInFlight(ci)
+		defer ci.stopInFlight()
+		o.logPayload(ci, PayloadReceived, req)
+
+		ctx, cancel := o.withDerivedDeadline(ctx, ci)
+		defer cancel()
+		ctx = ctxzerolog.New(withDegradation(o.withInboundDeadline(ctx, ci), ci), ci.log.Logger())
+		ci.handlerStart = time.Now()
+		res, err := handler(ctx, req)
+		ci.handlerDuration = time.Since(ci.handlerStart)
+		if err == nil {
+			ci.countMessage(true)
+			o.countBytes(ci, true, res)
+			o.recordMessageType(ci, true, res)
+			o.validateResponse(ci, res)
+			o.logPayload(ci, PayloadSent, res)
+			if ci.responses != nil {
+				ci.responses.add(res)
+			}
+		}
+		o.finish(ci, err, msgUnary)
 
 		return res, err
 	}
@@ -37,17 +105,69 @@ func NewUnaryServerInterceptor(logger zerolog.Logger, opts ...Option) grpc.Unary
 
 // NewUnaryClientInterceptor returns an unary client interceptor that logs the gRPC calls
 func NewUnaryClientInterceptor(logger zerolog.Logger, opts ...Option) grpc.UnaryClientInterceptor {
-	o := evaluateOptions(opts)
+	o := evaluateClientOptions(opts)
+	return o.unaryClientInterceptor(o.fixedLogger(logger))
+}
+
+// UnaryClientInterceptorProvider returns an unary client interceptor like NewUnaryClientInterceptor,
+// but obtains the logger of each call from the provider
+func UnaryClientInterceptorProvider(p LoggerProvider, opts ...Option) grpc.UnaryClientInterceptor {
+	o := evaluateClientOptions(opts)
+	return o.unaryClientInterceptor(o.decorateProvider(p))
+}
+
+func (o *options) unaryClientInterceptor(provider LoggerProvider) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		start := time.Now()
+		logger := provider(ctx, method)
+		if o.optedOut(logger) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		ctx = o.onStartContext(ctx, method)
+		ci := o.newCall(ctx, logger, method, false)
+		ci.countsBytes = o.summaryCollector != nil
+		o.enterCall(ci)
+		defer o.exitCall(ci)
+		o.recordServiceConfig(ci, cc)
+		o.recordCompressionDecision(ci, opts)
+		o.retainCall(ci, 1)
+		if ci.requests != nil {
+			ci.requests.add(req)
+		}
+		if o.deferredEmission {
+			defer o.flushStartOnPanic(ci, msgStartUnary)
+		}
+		o.logStart(ci, msgStartUnary)
+		ci.countMessage(true)
+		o.countBytes(ci, true, req)
+		o.recordMessageType(ci, true, req)
+		o.addCost(ci, true, req)
+		o.startInFlight(ci)
+		defer ci.stopInFlight()
+		o.logPayload(ci, PayloadSent, req)
 
+		if o.tlsField || o.protocolField || o.summaryCollector != nil {
+			ci.peer = &peer.Peer{}
+			opts = appendCallOption(opts, grpc.Peer(ci.peer))
+		}
+		if len(o.headerCaptureKeys) > 0 {
+			ci.header = &metadata.MD{}
+			opts = appendCallOption(opts, grpc.Header(ci.header))
+		}
+		if o.compressionRatio || o.timeToHeader {
+			ci.stats = &callStats{}
+			ctx = context.WithValue(ctx, callStatsKey{}, ci.stats)
+		}
 		err := invoker(ctx, method, req, reply, cc, opts...)
-		if !o.shouldLog(method, err) {
-			return err
+		if err == nil {
+			ci.countMessage(false)
+			o.countBytes(ci, false, reply)
+			o.recordMessageType(ci, false, reply)
+			o.logPayload(ci, PayloadReceived, reply)
+			if ci.responses != nil {
+				ci.responses.add(reply)
+			}
 		}
-
-		l := initLog(ctx, logger, method)
-		doInterceptorLog(l, start, err, msgUnary, o.levelFunc)
+		o.finish(ci, err, msgUnary)
 
 		return err
 	}
@@ -56,46 +176,128 @@ func NewUnaryClientInterceptor(logger zerolog.Logger, opts ...Option) grpc.Unary
 // NewStreamServerInterceptor returns a streaming server interceptor that adds zerolog to context and logs the gRPC calls
 func NewStreamServerInterceptor(logger zerolog.Logger, opts ...Option) grpc.StreamServerInterceptor {
 	o := evaluateOptions(opts)
-	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		start := time.Now()
+	return o.streamServerInterceptor(o.fixedLogger(logger))
+}
 
-		wrapped := wrapServerStream(stream)
-		l := initLog(wrapped.wrappedContext, logger, info.FullMethod)
-		wrapped.wrappedContext = ctxzerolog.New(wrapped.wrappedContext, l.Logger())
+// StreamServerInterceptorProvider returns a streaming server interceptor like NewStreamServerInterceptor,
+// but obtains the logger of each call from the provider
+func StreamServerInterceptorProvider(p LoggerProvider, opts ...Option) grpc.StreamServerInterceptor {
+	o := evaluateOptions(opts)
+	return o.streamServerInterceptor(o.decorateProvider(p))
+}
 
-		err := handler(srv, wrapped)
-		if !o.shouldLog(info.FullMethod, err) {
-			return err
+func (o *options) streamServerInterceptor(provider LoggerProvider) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		logger := provider(stream.Context(), info.FullMethod)
+		if o.optedOut(logger) {
+			return handler(srv, stream)
+		}
+		wrapped := wrapServerStream(stream)
+		wrapped.wrappedContext = o.onStartContext(wrapped.wrappedContext, info.FullMethod)
+		ci := o.newCall(wrapped.wrappedContext, logger, info.FullMethod, true)
+		ci.stream, ci.countsBytes = true, o.streamByteTotals || o.summaryCollector != nil
+		o.enterCall(ci)
+		defer o.exitCall(ci)
+		if o.wrapsStreams() {
+			wrapped.o, wrapped.ci = o, ci
+		}
+		if o.handlerNames != nil {
+			ci.handler = o.handlerNames.name(srv, info.FullMethod)
+		}
+		o.retainCall(ci, maxRetainedStreamMessages)
+		wrapped.requests, wrapped.responses = ci.requests, ci.responses
+		var cancel context.CancelFunc
+		wrapped.wrappedContext, cancel = o.withDerivedDeadline(wrapped.wrappedContext, ci)
+		defer cancel()
+		wrapped.wrappedContext = ctxzerolog.New(withDegradation(o.withInboundDeadline(wrapped.wrappedContext, ci), ci), ci.log.Logger())
+		wrapped.wrappedContext = context.WithValue(wrapped.wrappedContext, messageLevelKey{}, ci.eventLevel(o.levelFunc(codes.OK)))
+		if o.streamContextModifier != nil {
+			if ctx := o.streamContextModifier(wrapped.wrappedContext, info); ctx != nil {
+				wrapped.wrappedContext = ctx
+			}
+		}
+		if o.deferredEmission {
+			defer o.flushStartOnPanic(ci, msgStartStream)
 		}
+		if o.recoverPanics {
+			defer o.recoverPanic(ci, &err, msgServerStream)
+		}
+		o.logStart(ci, msgStartStream)
+		o.startInFlight(ci)
+		defer ci.stopInFlight()
 
-		doInterceptorLog(l, start, err, msgServerStream, o.levelFunc)
+		ci.handlerStart = time.Now()
+		err = handler(srv, wrapped)
+		ci.handlerDuration = time.Since(ci.handlerStart)
+		o.finish(ci, err, msgServerStream)
 
 		return err
 	}
 }
 
-// NewStreamClientInterceptor returns a streaming client interceptor that logs the gRPC calls
+// NewStreamClientInterceptor returns a streaming client interceptor that logs the gRPC calls.
+// The FinishCall event is logged when RecvMsg of the returned stream returns io.EOF (codes.OK) or the terminal error.
 func NewStreamClientInterceptor(logger zerolog.Logger, opts ...Option) grpc.StreamClientInterceptor {
-	o := evaluateOptions(opts)
+	o := evaluateClientOptions(opts)
+	return o.streamClientInterceptor(o.fixedLogger(logger))
+}
+
+// StreamClientInterceptorProvider returns a streaming client interceptor like NewStreamClientInterceptor,
+// but obtains the logger of each call from the provider
+func StreamClientInterceptorProvider(p LoggerProvider, opts ...Option) grpc.StreamClientInterceptor {
+	o := evaluateClientOptions(opts)
+	return o.streamClientInterceptor(o.decorateProvider(p))
+}
+
+func (o *options) streamClientInterceptor(provider LoggerProvider) grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-		start := time.Now()
+		logger := provider(ctx, method)
+		if o.optedOut(logger) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		ctx = o.onStartContext(ctx, method)
+		ci := o.newCall(ctx, logger, method, false)
+		ci.stream, ci.countsBytes = true, o.streamByteTotals || o.summaryCollector != nil
+		o.retainCall(ci, maxRetainedStreamMessages)
+		o.recordServiceConfig(ci, cc)
+		o.recordCompressionDecision(ci, opts)
+		o.enterCall(ci)
+		o.logStart(ci, msgStartStream)
+		o.startInFlight(ci)
+		if o.tlsField || o.protocolField || o.summaryCollector != nil {
+			ci.peer = &peer.Peer{}
+			opts = appendCallOption(opts, grpc.Peer(ci.peer))
+		}
+		if len(o.headerCaptureKeys) > 0 {
+			ci.header = &metadata.MD{}
+			opts = appendCallOption(opts, grpc.Header(ci.header))
+		}
+		if o.timeToHeader {
+			ci.stats = &callStats{}
+			ctx = context.WithValue(ctx, callStatsKey{}, ci.stats)
+		}
 
 		cs, err := streamer(ctx, desc, cc, method, opts...)
-		if !o.shouldLog(method, err) {
+		if err != nil {
+			o.finish(ci, err, msgClientStream)
 			return cs, err
 		}
 
-		l := initLog(ctx, logger, method)
-		doInterceptorLog(l, start, err, msgClientStream, o.levelFunc)
-
-		return cs, err
+		// the call finishes when the stream ends, the caller must read it until an error or cancel the context
+		return &wrappedClientStream{ClientStream: cs, o: o, ci: ci, serverStreams: desc.ServerStreams}, nil
 	}
 }
 
+// appendCallOption appends the call option of the interceptor without modifying the options of the caller
+func appendCallOption(opts []grpc.CallOption, opt grpc.CallOption) []grpc.CallOption {
+	return append(opts[:len(opts):len(opts)], opt)
+}
+
 func initLog(ctx context.Context, logger zerolog.Logger, fullMethodString string) zerolog.Context {
-	service := path.Dir(fullMethodString)[1:]
-	method := path.Base(fullMethodString)
+	return initLogFields(ctx, logger, path.Dir(fullMethodString)[1:], path.Base(fullMethodString))
+}
 
+func initLogFields(ctx context.Context, logger zerolog.Logger, service, method string) zerolog.Context {
 	with := logger.With().
 		Str("grpc.service", service).
 		Str("grpc.method", method)
@@ -111,30 +313,607 @@ func initLog(ctx context.Context, logger zerolog.Logger, fullMethodString string
 	return with
 }
 
+// initLog prepares the logger context with the common call fields and the fields enabled by options
+func (o *options) initLog(ctx context.Context, logger zerolog.Logger, fullMethodString string, ci *callInfo) zerolog.Context {
+	service, method := splitMethod(fullMethodString)
+	if o.shortServiceNames {
+		service = shortServiceName(service)
+	}
+	with := initLogFields(ctx, logger, service, method)
+	if o.fullMethodField {
+		with = with.Str("grpc.full_method", fullMethodString)
+	}
+	if ci.burst {
+		with = with.Bool("grpc.burst", true)
+	}
+	if ci.requestID != "" {
+		with = withRequestID(ci, with)
+	}
+	if ci.shortTraceID != "" {
+		with = with.Str("trace_id_short", ci.shortTraceID)
+	}
+	if ctx == nil {
+		return with
+	}
+	if o.tlsPeerFields {
+		with = withTLSPeerFields(ctx, with)
+	}
+	if o.hashedPeer {
+		with = withHashedPeerField(ctx, with, o.peerHashSalt)
+	}
+	if o.tlsField && ci.server {
+		p, _ := peer.FromContext(ctx)
+		with = withTLSField(p, with)
+	}
+	if o.protocolField && ci.server {
+		p, _ := peer.FromContext(ctx)
+		with = withProtocolField(p, with)
+	}
+	if ci.server {
+		with = o.withIncomingMetadataFields(ctx, with)
+	} else if o.deadlineSkew {
+		with = o.withDeadlineSkew(ctx, with)
+	}
+	if !ci.server && o.deadlineLogging {
+		with = withClientDeadline(ci, with)
+	}
+	if o.tagsExtractor != nil {
+		with = o.withCtxTags(ctx, with)
+	}
+	if len(o.contextValueSpecs) > 0 {
+		with = o.withContextValues(ctx, with)
+	}
+	return with
+}
+
+// shortServiceName strips the proto package from the service name, e.g. "mycompany.orders.v1.OrderService" becomes "OrderService"
+func shortServiceName(service string) string {
+	if i := strings.LastIndexByte(service, '.'); i >= 0 {
+		return service[i+1:]
+	}
+	return service
+}
+
 type message string
 
-func doInterceptorLog(log zerolog.Context, start time.Time, callError error, msg message, ctl CodeToLevel) {
+// callInfo holds the state of the call captured when the call starts
+type callInfo struct {
+	// the counters of the payload events, the first for 64-bit alignment of atomic access
+	sentPayloads     int64
+	receivedPayloads int64
+	// the counters of the messages for the heartbeat lines
+	sentMessages     int64
+	receivedMessages int64
+	// the serialized size of the stream messages for WithStreamByteTotals
+	sentBytes       int64
+	receivedBytes   int64
+	cost            uint64 // the float64 bits of the cost of WithCostFunction
+	firstReceived   int32  // set when the first message of the stream is received
+	inflightCounted int32  // set while the call is counted by WithInflightCount
+	bytesPartial    int32  // set when a non-proto stream message is not counted by WithStreamByteTotals
+	finished        int32  // set when the call is finished, the later finishes are ignored
+
+	ctx               context.Context
+	fullMethod        string
+	loggedMethod      string // the full method name formatted by WithMethodNameFormatter
+	server            bool   // the call is handled by the server interceptor
+	log               zerolog.Context
+	payloadLog        zerolog.Context // the logger context of the payload events
+	start             time.Time
+	deadline          time.Time // zero if the call has no deadline
+	startPending      bool      // the StartCall event is deferred until the finish
+	sampled           bool      // the call is chosen by the sampler to be logged
+	payloadSampled    bool      // the payloads of the call are chosen to be logged
+	debugPayloads     bool      // the payload events are enabled by the debug header
+	requests          *retainedMessages
+	responses         *retainedMessages
+	handler           string        // the name of the Go method serving the call
+	handlerStart      time.Time     // set immediately before the handler is called
+	handlerDuration   time.Duration // zero if the handler panicked
+	callCount         int64
+	panicValue        interface{}
+	panicStack        []byte
+	stats             *callStats // filled by the client stats handler
+	peer              *peer.Peer // the peer of the client call filled by grpc.Peer
+	inFlight          *inFlightTimer
+	countsBytes       bool         // the stream counts the size of the messages for WithStreamByteTotals
+	requestType       atomic.Value // the name of the first request message for WithMessageTypeFields
+	responseType      atomic.Value
+	stream            bool                              // the call is handled by a stream interceptor
+	eventLogs         map[LoggableEvent]zerolog.Context // the logger contexts of the events routed by WithEventLoggers
+	invalidResponse   atomic.Value                      // the first validation error of WithResponseValidator
+	methodConfig      *grpc.MethodConfig                // the method config of the client call for WithServiceConfigField
+	degraded          *degradation                      // the mark of MarkDegraded, nil for the client calls
+	requestCompressed *bool                             // the compression of the client requests for WithCompressionDecisionField
+	appliedTimeout    time.Duration                     // the timeout applied by WithMetadataDerivedDeadline
+	burst             bool                              // the call arrived during the burst of WithBurstDetection
+	header            *metadata.MD                      // the header of the client call for WithHeaderCaptureAllowlist
+	forcedLevel       zerolog.Level                     // the level of WithContextLevel, set if levelForced
+	levelForced       bool
+	span              Span   // the span of WithSpanEvents
+	requestID         string // the ID of WithRequestIDFromTrace
+	shortTraceID      string // the prefix of WithShortTraceID
+}
+
+// logContext returns the copy of the logger context of the call to add the fields of an event. The fields must not
+// be added to ci.log directly: its buffer has spare capacity, so the events of the concurrent SendMsg and RecvMsg
+// would append to the same memory.
+func (ci *callInfo) logContext() zerolog.Context {
+	l := ci.log.Logger()
+	return l.With()
+}
+
+// newCall captures the state of the call and prepares its logger context
+func (o *options) newCall(ctx context.Context, logger zerolog.Logger, fullMethod string, server bool) *callInfo {
+	ci := &callInfo{ctx: ctx, fullMethod: fullMethod, loggedMethod: o.formatMethod(fullMethod), server: server, start: time.Now()}
+	if ctx != nil {
+		if d, ok := ctx.Deadline(); ok {
+			ci.deadline = d
+		}
+	}
+	logger = o.forceLevel(ctx, ci, logger)
+	ci.sampled = ci.levelForced || o.sampler == nil || o.sampler.Sample(o.levelFunc(codes.OK))
+	ci.burst = o.burst != nil && o.burst.arrive(fullMethod, ci.start)
+	o.initRequestID(ctx, ci)
+	o.initShortTraceID(ctx, ci)
+	ci.log = o.initLog(ctx, logger, ci.loggedMethod, ci)
+	ci.payloadLog = ci.log
+	ci.debugPayloads = server && ctx != nil && o.debugHeader != "" && o.hasDebugHeader(ctx)
+	ci.payloadSampled = ci.debugPayloads || (o.logsPayloads() && o.samplePayloads())
+	if o.payloadLogger != nil && ci.payloadSampled {
+		ci.payloadLog = o.initLog(ctx, *o.payloadLogger, ci.loggedMethod, ci)
+	}
+	o.initEventLogs(ctx, ci)
+	o.initSpan(ctx, ci)
+	return ci
+}
+
+// onStartContext calls the WithOnStart callback and returns the context of the rest of the call
+func (o *options) onStartContext(ctx context.Context, fullMethod string) context.Context {
+	if o.onStart == nil {
+		return ctx
+	}
+	if c := o.onStart(ctx, fullMethod); c != nil {
+		return c
+	}
+	return ctx
+}
+
+// logStart logs the StartCall event, or keeps it for the finish line if the emission is deferred
+func (o *options) logStart(ci *callInfo, msg message) {
+	if !o.hasEvent(StartCall) || !ci.sampled || !o.decide(ci.ctx, ci.fullMethod, nil) || !o.filterEvent(StartCall, ci.fullMethod, codes.OK) {
+		return
+	}
+	if o.deferredEmission {
+		ci.startPending = true
+		return
+	}
+	with := o.withInflightCount(ci, ci.eventLog(StartCall))
+	l := with.Logger()
+	level := ci.eventLevel(o.levelFunc(codes.OK))
+	e := l.WithLevel(level)
+	o.runEventHook(ci, StartCall, e)
+	e.Msg(string(msg))
+	o.runEventCallback(ci, StartCall, with, level, msg, nil)
+}
+
+// flushStartOnPanic emits the deferred StartCall event if the call panics before it finished.
+// It must be called with defer
+func (o *options) flushStartOnPanic(ci *callInfo, msg message) {
+	if !ci.startPending {
+		return
+	}
+	r := recover()
+	if r == nil {
+		return
+	}
+	with := o.withInflightCount(ci, ci.eventLog(StartCall))
+	l := with.Logger()
+	level := ci.eventLevel(o.levelFunc(codes.OK))
+	e := l.WithLevel(level)
+	o.runEventHook(ci, StartCall, e)
+	e.Msg(string(msg))
+	o.runEventCallback(ci, StartCall, with, level, msg, nil)
+	panic(r)
+}
+
+// recoverPanic recovers the panic of the handler, logs it with the FinishCall event and sets the error returned to the caller.
+// It must be called with defer
+func (o *options) recoverPanic(ci *callInfo, err *error, msg message) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	ci.panicValue = r
+	ci.panicStack = debug.Stack()
+	if o.panicHandler != nil {
+		*err = o.panicHandler(r)
+	} else {
+		*err = status.Errorf(codes.Internal, "panic: %v", r)
+	}
+	o.finish(ci, *err, msg)
+}
+
+// finish handles the finish of the call
+func (o *options) finish(ci *callInfo, callError error, msg message) {
+	if !atomic.CompareAndSwapInt32(&ci.finished, 0, 1) {
+		return
+	}
+	ci.stopInFlight()
+	elapsed := time.Since(ci.start)
+	if o.summaryCollector != nil {
+		o.collectSummary(ci, callError, elapsed)
+	}
+	o.setSpanError(ci, status.Code(callError), callError)
+	if o.summary != nil {
+		o.summary.observe(ci.fullMethod, status.Code(callError), elapsed)
+	}
+	if o.summarizes(ci.fullMethod) {
+		o.periodicSummary.observe(ci.fullMethod, status.Code(callError), elapsed)
+		o.exitCall(ci)
+		return
+	}
+	if o.callCounts != nil {
+		ci.callCount = o.callCounts.add(ci.fullMethod, 1)
+	}
+	if !o.decide(ci.ctx, ci.fullMethod, callError) {
+		o.exitCall(ci)
+		return
+	}
+	o.logFinish(ci, callError, msg, elapsed)
+	o.exitCall(ci)
+}
+
+// logFinish logs the FinishCall event
+func (o *options) logFinish(ci *callInfo, callError error, msg message, elapsed time.Duration) {
+	if !o.hasEvent(FinishCall) {
+		return
+	}
 	code := status.Code(callError)
-	with := log.Str("grpc.code", code.String()).Dur("grpc.time_ms", time.Since(start))
-	if callError != nil {
-		with = with.Err(callError)
+	if !o.filterEvent(FinishCall, ci.fullMethod, code) {
+		return
+	}
+	if code == codes.OK && elapsed < o.minDurationToLog {
+		return
+	}
+	level := ci.eventLevel(o.finishLevel(ci.fullMethod, code))
+	if !ci.sampled && (level < zerolog.ErrorLevel || !o.sampler.Sample(level)) {
+		return
+	}
+	if callError != nil && o.errorDedup != nil && o.errorDedup.suppress(ci, code, callError, level, func() zerolog.Context {
+		return o.repeatLog(ci, code, callError)
+	}) {
+		return
+	}
+
+	log := o.withInflightCount(ci, ci.eventLogContext(FinishCall))
+	if ci.startPending {
+		ci.startPending = false
+		log = log.Dict("grpc.start", zerolog.Dict().Time("time", ci.start))
+	}
+
+	with := o.durationFunc(log.Str("grpc.code", o.codeName(code)), elapsed.Round(o.durationRounding))
+	if o.httpStatusFunc != nil {
+		with = with.Int("grpc.http_status", o.httpStatusFunc(code))
+	}
+	hideMessages := o.hidesErrorMessages(code)
+	if ci.panicValue != nil {
+		if !hideMessages {
+			with = with.Str("grpc.panic", o.truncateValue(fmt.Sprint(ci.panicValue)))
+		}
+		with = with.Bytes("grpc.panic.stack", ci.panicStack)
+	}
+	if callError != nil && hideMessages {
+		with = o.withPublicError(with, code, callError)
+		if o.structuredStatus {
+			with = withStructuredStatus(with, callError, false)
+		}
+	} else if callError != nil {
+		if !o.noErrorField {
+			with = o.withError(with, callError)
+		}
+		with = with.Str("grpc.status_message", o.truncateValue(statusFromError(callError).Message()))
+		if o.rootCauseField {
+			with = o.withRootCause(with, callError)
+		}
+		if o.structuredStatus {
+			with = withStructuredStatus(with, callError, true)
+		}
+	}
+	if callError != nil && o.errorOrigin != nil {
+		with = o.withErrorOrigin(with, callError)
+	}
+	if callError != nil && o.errorGroup != nil {
+		with = o.withErrorGroup(with, callError)
+	}
+	if ci.requests != nil && o.logsRetainedPayloads(code) {
+		renderPanic := recoverRenderPanic(ci.log, func() {
+			with = ci.requests.withContent(with)
+			if ci.responses != nil {
+				with = ci.responses.withContent(with)
+			}
+		})
+		if renderPanic != nil {
+			with = with.Str("grpc.payload.render_panic", fmt.Sprint(renderPanic))
+		}
+	}
+	if o.cancelCauseField && ci.ctx != nil && !hideMessages {
+		with = o.withCancelCause(ci.ctx, with)
+	}
+	if code == codes.DeadlineExceeded && !ci.deadline.IsZero() {
+		with = withDeadlineExceeded(with, ci, elapsed)
+	}
+	if o.callCounts != nil {
+		with = with.Int64("grpc.method.count", ci.callCount)
+	}
+	if ci.stats != nil && o.compressionRatio {
+		if ratio, ok := ci.stats.compressionRatio(); ok {
+			with = with.Float64("grpc.response.compression_ratio", ratio)
+		}
+	}
+	if ci.stats != nil && o.timeToHeader {
+		if d, ok := ci.stats.timeToHeader(ci.start); ok {
+			with = with.Dur("grpc.time_to_header_ms", d)
+		}
+	}
+	if ci.stream && o.streamByteTotals {
+		with = withStreamBytes(ci, with)
+	}
+	if ci.methodConfig != nil {
+		with = withServiceConfig(ci, with)
+	}
+	if ci.requestCompressed != nil {
+		with = withCompressionDecision(ci, with)
+	}
+	if o.messageTypeFields {
+		with = withMessageTypes(ci, with)
+	}
+	if o.costFunc != nil {
+		with = withCost(ci, with)
+	}
+	if o.responseValidator != nil {
+		with = o.withInvalidResponse(ci, with)
+	}
+	if ci.degraded != nil {
+		with = o.withDegraded(ci, with)
+	}
+	if o.cacheHitKey != nil && ci.ctx != nil {
+		with = o.withCacheHit(ci.ctx, with)
+	}
+	if ci.appliedTimeout > 0 {
+		with = withAppliedTimeout(ci, with)
+	}
+	if ci.handler != "" {
+		with = with.Str("grpc.handler", ci.handler)
+	}
+	if o.handlerInvokedField && ci.server {
+		with = with.Bool("grpc.handler_invoked", !ci.handlerStart.IsZero())
+	}
+	if o.handlerDurationField && !ci.handlerStart.IsZero() {
+		d := ci.handlerDuration
+		if d == 0 {
+			d = time.Since(ci.handlerStart)
+		}
+		with = with.Dur("grpc.handler_duration_ms", d)
+	}
+	if ci.peer != nil && o.tlsField {
+		with = withTLSField(ci.peer, with)
+	}
+	if ci.peer != nil && o.protocolField {
+		with = withProtocolField(ci.peer, with)
+	}
+	if ci.header != nil {
+		with = o.withCapturedHeader(*ci.header, with)
+	}
+	if o.deadlineRemaining && !ci.deadline.IsZero() {
+		with = with.Dur("grpc.deadline_remaining_ms", time.Until(ci.deadline))
+	}
+	if o.eventHook != nil && !o.customMessageProducer {
+		l := with.Logger()
+		e := l.WithLevel(level)
+		o.runEventHook(ci, FinishCall, e)
+		e.Msg(string(msg))
+	} else {
+		o.messageProducer(ci.ctx, with, string(msg), level, code, callError, CallOptions{o})
+	}
+	o.runEventCallback(ci, FinishCall, with, level, msg, nil)
+}
+
+// withDeadlineExceeded adds the fields that tell whether the deadline budget given by the caller
+// was too tight or the call was too slow
+func withDeadlineExceeded(with zerolog.Context, ci *callInfo, elapsed time.Duration) zerolog.Context {
+	budget := ci.deadline.Sub(ci.start)
+	with = with.Dur("grpc.deadline_budget_ms", budget).Dur("grpc.deadline_exceeded_after_ms", elapsed)
+	if budget > 0 {
+		with = with.Float64("grpc.deadline_consumed_pct", float64(elapsed)/float64(budget)*100)
+	}
+	return with
+}
+
+// wrapsStreams reports whether the streams have to be wrapped to observe their messages
+func (o *options) wrapsStreams() bool {
+	return o.logsPayloads() || o.inFlightInterval > 0 || o.streamHeartbeat > 0 || o.hasEvent(StreamOpened) || o.perMessageSize || o.debugHeader != "" ||
+		o.hasEvent(PayloadSendFailed) || o.hasEvent(PayloadReceiveFailed) ||
+		o.streamByteTotals || o.messageTypeFields || o.costFunc != nil || o.responseValidator != nil || o.finishOnFirstError ||
+		o.summaryCollector != nil
+}
+
+// logStreamOpened logs the StreamOpened event on the first message received by the stream
+func (o *options) logStreamOpened(ci *callInfo) {
+	if !o.hasEvent(StreamOpened) || !atomic.CompareAndSwapInt32(&ci.firstReceived, 0, 1) {
+		return
 	}
+	if !ci.sampled || !o.decide(ci.ctx, ci.fullMethod, nil) || !o.filterEvent(StreamOpened, ci.fullMethod, codes.OK) {
+		return
+	}
+	with := ci.eventLogContext(StreamOpened).Dur("grpc.stream.first_recv_latency_ms", time.Since(ci.start))
 	l := with.Logger()
-	l.WithLevel(ctl(code)).Msg(string(msg))
+	level := ci.eventLevel(o.levelFunc(codes.OK))
+	e := l.WithLevel(level)
+	o.runEventHook(ci, StreamOpened, e)
+	e.Msg(string(msgStreamOpened))
+	o.runEventCallback(ci, StreamOpened, with, level, msgStreamOpened, nil)
+}
+
+// logRecvSize logs the size of the message received by the stream server at Debug if WithPerMessageSizeLogging is set
+func (o *options) logRecvSize(ci *callInfo, m interface{}) {
+	if !o.perMessageSize || !ci.sampled {
+		return
+	}
+	p, ok := m.(proto.Message)
+	if !ok {
+		return
+	}
+	l := ci.log.Logger()
+	e := l.Debug()
+	if e == nil || !o.decide(ci.ctx, ci.fullMethod, nil) {
+		return
+	}
+	e.Int("grpc.stream.recv_size", proto.Size(p)).Msg(string(msgStreamRecvSize))
+}
+
+// logStreamFailure logs the PayloadSendFailed or PayloadReceiveFailed event of the stream, io.EOF is not a failure
+func (o *options) logStreamFailure(ci *callInfo, event LoggableEvent, err error) {
+	if err == io.EOF || !o.hasEvent(event) || !ci.sampled || !o.decide(ci.ctx, ci.fullMethod, err) ||
+		!o.filterEvent(event, ci.fullMethod, status.Code(err)) {
+		return
+	}
+	index, msg := atomic.LoadInt64(&ci.sentMessages), msgSendFailed
+	if event == PayloadReceiveFailed {
+		index, msg = atomic.LoadInt64(&ci.receivedMessages), msgReceiveFailed
+	}
+	with := o.durationFunc(ci.eventLogContext(event).Int64("grpc.stream.msg_index", index), time.Since(ci.start).Round(o.durationRounding))
+	if code := status.Code(err); o.hidesErrorMessages(code) {
+		with = o.withPublicError(with, code, err)
+	} else if !o.noErrorField {
+		with = o.withError(with, err)
+	}
+	l := with.Logger()
+	level := ci.eventLevel(o.streamFailureLevel)
+	e := l.WithLevel(level)
+	o.runEventHook(ci, event, e)
+	e.Msg(string(msg))
+	o.runEventCallback(ci, event, with, level, msg, nil)
 }
 
 type wrappedServerStream struct {
 	grpc.ServerStream
 	wrappedContext context.Context
+	requests       *retainedMessages
+	responses      *retainedMessages
+	o              *options
+	ci             *callInfo
 }
 
 func (w *wrappedServerStream) Context() context.Context {
 	return w.wrappedContext
 }
 
-func wrapServerStream(stream grpc.ServerStream) *wrappedServerStream {
-	if existing, ok := stream.(*wrappedServerStream); ok {
-		return existing
+func (w *wrappedServerStream) SendMsg(m interface{}) error {
+	err := w.ServerStream.SendMsg(m)
+	if err == nil && w.responses != nil {
+		w.responses.add(m)
+	}
+	if w.ci == nil || w.o.finishedOnError(w.ci) {
+		return err
+	}
+	if err != nil {
+		w.o.logStreamFailure(w.ci, PayloadSendFailed, err)
+		w.o.finishOnStreamError(w.ci, err)
+		return err
+	}
+	w.ci.countMessage(true)
+	w.o.countBytes(w.ci, true, m)
+	w.o.recordMessageType(w.ci, true, m)
+	w.o.addCost(w.ci, true, m)
+	w.o.validateResponse(w.ci, m)
+	w.o.logPayload(w.ci, PayloadSent, m)
+	return nil
+}
+
+func (w *wrappedServerStream) RecvMsg(m interface{}) error {
+	err := w.ServerStream.RecvMsg(m)
+	if err == nil {
+		if w.requests != nil {
+			w.requests.add(m)
+		}
+		if w.ci != nil && !w.o.finishedOnError(w.ci) {
+			w.o.logStreamOpened(w.ci)
+			w.o.logRecvSize(w.ci, m)
+			w.ci.countMessage(false)
+			w.o.countBytes(w.ci, false, m)
+			w.o.recordMessageType(w.ci, false, m)
+			w.o.addCost(w.ci, false, m)
+			w.o.logPayload(w.ci, PayloadReceived, m)
+		}
+	} else if w.ci != nil && !w.o.finishedOnError(w.ci) {
+		w.o.logStreamFailure(w.ci, PayloadReceiveFailed, err)
+		w.o.finishOnStreamError(w.ci, err)
 	}
+	return err
+}
+
+func wrapServerStream(stream grpc.ServerStream) *wrappedServerStream {
 	return &wrappedServerStream{ServerStream: stream, wrappedContext: stream.Context()}
 }
+
+type wrappedClientStream struct {
+	grpc.ClientStream
+	o             *options
+	ci            *callInfo
+	serverStreams bool // the server sends a stream of messages, otherwise the first received message ends the call
+}
+
+// finish handles the finish of the stream once, err is the terminal error of the stream
+func (w *wrappedClientStream) finish(err error) {
+	w.o.finish(w.ci, err, msgClientStream)
+}
+
+func (w *wrappedClientStream) SendMsg(m interface{}) error {
+	err := w.ClientStream.SendMsg(m)
+	if err == nil && w.ci.requests != nil {
+		w.ci.requests.add(m)
+	}
+	if w.o.finishedOnError(w.ci) {
+		return err
+	}
+	if err != nil {
+		w.o.logStreamFailure(w.ci, PayloadSendFailed, err)
+		w.o.finishOnStreamError(w.ci, err)
+		return err
+	}
+	w.ci.countMessage(true)
+	w.o.countBytes(w.ci, true, m)
+	w.o.recordMessageType(w.ci, true, m)
+	w.o.addCost(w.ci, true, m)
+	w.o.logPayload(w.ci, PayloadSent, m)
+	return nil
+}
+
+func (w *wrappedClientStream) RecvMsg(m interface{}) error {
+	err := w.ClientStream.RecvMsg(m)
+	if err == nil && w.ci.responses != nil {
+		w.ci.responses.add(m)
+	}
+	if w.o.finishedOnError(w.ci) {
+		return err
+	}
+	switch {
+	case err == nil:
+		w.o.logStreamOpened(w.ci)
+		w.ci.countMessage(false)
+		w.o.countBytes(w.ci, false, m)
+		w.o.recordMessageType(w.ci, false, m)
+		w.o.addCost(w.ci, false, m)
+		w.o.logPayload(w.ci, PayloadReceived, m)
+		if !w.serverStreams {
+			w.finish(nil)
+		}
+	case err == io.EOF:
+		w.finish(nil)
+	default:
+		w.o.logStreamFailure(w.ci, PayloadReceiveFailed, err)
+		w.finish(err)
+	}
+	return err
+}
@@ -0,0 +1,473 @@
+package grpc_zerolog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pereslava/grpc_zerolog"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testMethod = "/grpc_zerolog.test.TestService/Ping"
+
+// logLines decodes the JSON log lines written to the buffer
+func logLines(t *testing.T, b *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, s := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if s == "" {
+			continue
+		}
+		m := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			t.Fatalf("invalid log line %q: %v", s, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+// callUnary calls the unary server interceptor with the handler returning err
+func callUnary(i grpc.UnaryServerInterceptor, req interface{}, err error) {
+	_, _ = i(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, err
+	})
+}
+
+type panickingStringer struct{}
+
+func (panickingStringer) String() string {
+	panic("broken String method")
+}
+
+func TestPayloadRenderingPanicIsRecovered(t *testing.T) {
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.PayloadReceived, grpc_zerolog.FinishCall))
+
+	_, err := i(context.Background(), panickingStringer{}, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatalf("got error %v, want the call to succeed", err)
+	}
+
+	lines := logLines(t, b)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want the panic, payload and finish lines", len(lines))
+	}
+	if lines[0]["level"] != "warn" || lines[0]["grpc.panic"] != "broken String method" || lines[0]["grpc.panic.stack"] == nil {
+		t.Errorf("got panic line %v", lines[0])
+	}
+	if lines[1]["message"] != "payload received" || lines[1]["grpc.payload.render_panic"] != "broken String method" {
+		t.Errorf("got payload line %v", lines[1])
+	}
+	if lines[2]["message"] != "finished unary call" || lines[2]["grpc.code"] != "OK" {
+		t.Errorf("got finish line %v", lines[2])
+	}
+}
+
+type fakeClientStream struct {
+	grpc.ClientStream
+	recvErr error
+}
+
+func (s *fakeClientStream) RecvMsg(m interface{}) error {
+	return s.recvErr
+}
+
+func (s *fakeClientStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+func TestStreamClientFinishesOnRecvMsgError(t *testing.T) {
+	for _, tc := range []struct {
+		recvErr  error
+		wantCode string
+	}{
+		{io.EOF, "OK"},
+		{status.Error(codes.Unavailable, "connection lost"), "Unavailable"},
+	} {
+		b := &bytes.Buffer{}
+		i := grpc_zerolog.NewStreamClientInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall))
+		streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return &fakeClientStream{recvErr: tc.recvErr}, nil
+		}
+
+		cs, err := i(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, testMethod, streamer)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if lines := logLines(t, b); len(lines) != 0 {
+			t.Fatalf("got %d lines when the stream is established, want none", len(lines))
+		}
+		if err := cs.RecvMsg(nil); err != tc.recvErr {
+			t.Fatalf("got RecvMsg error %v, want %v", err, tc.recvErr)
+		}
+		_ = cs.RecvMsg(nil)
+
+		lines := logLines(t, b)
+		if len(lines) != 1 {
+			t.Fatalf("%v: got %d lines, want one FinishCall line", tc.recvErr, len(lines))
+		}
+		if lines[0]["message"] != "finished stream call" || lines[0]["grpc.code"] != tc.wantCode || lines[0]["grpc.time_ms"] == nil {
+			t.Errorf("%v: got finish line %v", tc.recvErr, lines[0])
+		}
+	}
+}
+
+func TestStreamClientRetainsRequestsOnError(t *testing.T) {
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewStreamClientInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall), grpc_zerolog.WithPayloadOnError())
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeClientStream{recvErr: status.Error(codes.Unavailable, "connection lost")}, nil
+	}
+	cs, err := i(context.Background(), &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, nil, testMethod, streamer)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	_ = cs.SendMsg(wrapperspb.String("request"))
+	_ = cs.RecvMsg(&wrapperspb.StringValue{})
+
+	lines := logLines(t, b)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want one FinishCall line", len(lines))
+	}
+	if got, want := lines[0]["grpc.request.content"], []interface{}{"request"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got request content %v, want the sent message", got)
+	}
+}
+
+func messages(lines []map[string]interface{}) []string {
+	var msgs []string
+	for _, l := range lines {
+		msgs = append(msgs, fmt.Sprint(l["message"]))
+	}
+	return msgs
+}
+
+func TestRequestPayloadIsLoggedBeforeHandler(t *testing.T) {
+	allEvents := grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall, grpc_zerolog.PayloadReceived, grpc_zerolog.PayloadSent, grpc_zerolog.FinishCall)
+	info := &grpc.UnaryServerInfo{FullMethod: testMethod}
+
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), allEvents)
+	_, _ = i(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	})
+	want := []string{"started unary call", "payload received", "payload sent", "finished unary call"}
+	if got := messages(logLines(t, b)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+
+	b.Reset()
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = i(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("handler failed")
+		})
+	}()
+	lines := logLines(t, b)
+	want = []string{"started unary call", "payload received"}
+	if got := messages(lines); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got events %q of the panicking handler, want %q", got, want)
+	}
+	if lines[1]["grpc.request.content"] != "request" {
+		t.Errorf("got payload line %v, want the request content", lines[1])
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+	return nil
+}
+
+func TestStreamRequestPayloadIsLoggedOnRecvMsg(t *testing.T) {
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewStreamServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.PayloadReceived))
+	func() {
+		defer func() { _ = recover() }()
+		_ = i(nil, &fakeServerStream{}, &grpc.StreamServerInfo{FullMethod: testMethod}, func(srv interface{}, stream grpc.ServerStream) error {
+			m := "request"
+			if err := stream.RecvMsg(&m); err != nil {
+				return err
+			}
+			panic("handler failed")
+		})
+	}()
+	want := []string{"payload received"}
+	if got := messages(logLines(t, b)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+}
+
+func TestInflightCountIsSharedAndPanicSafe(t *testing.T) {
+	b := &bytes.Buffer{}
+	opt := grpc_zerolog.WithInflightCount()
+	unary := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), opt, grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall))
+	stream := grpc_zerolog.NewStreamServerInterceptor(zerolog.New(b), opt, grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall))
+	info := &grpc.UnaryServerInfo{FullMethod: testMethod}
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = unary(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("handler failed")
+		})
+	}()
+	_ = stream(nil, &fakeServerStream{}, &grpc.StreamServerInfo{FullMethod: testMethod}, func(srv interface{}, s grpc.ServerStream) error {
+		_, err := unary(s.Context(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return err
+	})
+
+	var got []interface{}
+	for _, l := range logLines(t, b) {
+		got = append(got, l["grpc.inflight"])
+	}
+	if want := []interface{}{1.0, 1.0, 2.0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got in-flight counts %v, want %v", got, want)
+	}
+}
+
+func TestDisabledProviderStillAppliesDerivedDeadline(t *testing.T) {
+	b := &bytes.Buffer{}
+	provider := func(context.Context, string) zerolog.Logger { return zerolog.New(b).Level(zerolog.Disabled) }
+	i := grpc_zerolog.UnaryServerInterceptorProvider(provider, grpc_zerolog.WithMetadataDerivedDeadline("x-soft-timeout"))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-soft-timeout", "1s"))
+	var deadline bool
+	_, _ = i(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, deadline = ctx.Deadline()
+		return nil, nil
+	})
+	if !deadline {
+		t.Error("the derived deadline is not applied for the disabled logger")
+	}
+	if b.Len() != 0 {
+		t.Errorf("got log lines %q, want none", b.String())
+	}
+}
+
+func TestErrorDeduplication(t *testing.T) {
+	b := &syncBuffer{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+		grpc_zerolog.WithErrorDeduplication(20*time.Millisecond))
+	for n := 0; n < 3; n++ {
+		callUnary(i, nil, status.Error(codes.Unavailable, "backend down"))
+	}
+	callUnary(i, nil, status.Error(codes.Internal, "other"))
+
+	b.mu.Lock()
+	lines := logLines(t, &b.b)
+	b.mu.Unlock()
+	if len(lines) != 2 || lines[0]["grpc.code"] != "Unavailable" || lines[1]["grpc.code"] != "Internal" {
+		t.Fatalf("got lines %v, want the first Unavailable and the Internal errors", lines)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	b.mu.Lock()
+	lines = logLines(t, &b.b)
+	b.mu.Unlock()
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines after the window, want the repeat line", len(lines))
+	}
+	if l := lines[2]; l["message"] != "repeated call errors" || l["grpc.code"] != "Unavailable" || l["grpc.error.repeat_count"] != 2.0 {
+		t.Errorf("got repeat line %v", l)
+	}
+}
+
+func TestDeferredStartIsFlushedOnPanic(t *testing.T) {
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall, grpc_zerolog.FinishCall),
+		grpc_zerolog.WithDeferredEmission())
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		_, _ = i(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("handler failed")
+		})
+	}()
+	if recovered != "handler failed" {
+		t.Errorf("got panic %v, want the handler panic to propagate", recovered)
+	}
+	want := []string{"started unary call"}
+	if got := messages(logLines(t, b)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+}
+
+func TestMetadataDerivedDeadline(t *testing.T) {
+	for _, tc := range []struct {
+		header   string
+		existing time.Duration // zero for no deadline
+		want     time.Duration // the expected remaining time, zero for no deadline
+		applied  bool
+	}{
+		{"50ms", time.Hour, 50 * time.Millisecond, true},
+		{"1h", 50 * time.Millisecond, 50 * time.Millisecond, false},
+		{"50ms", 0, 50 * time.Millisecond, true},
+		{"soon", 0, 0, false},
+		{"-1s", 0, 0, false},
+	} {
+		b := &bytes.Buffer{}
+		i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+			grpc_zerolog.WithMetadataDerivedDeadline("X-Soft-Timeout"))
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-soft-timeout", tc.header))
+		if tc.existing > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, tc.existing)
+			defer cancel()
+		}
+		var remaining time.Duration
+		_, _ = i(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			if d, ok := ctx.Deadline(); ok {
+				remaining = time.Until(d)
+			}
+			return nil, nil
+		})
+		if tc.want == 0 && remaining != 0 || tc.want > 0 && (remaining <= 0 || remaining > tc.want) {
+			t.Errorf("%s with %v: got remaining %v, want at most %v", tc.header, tc.existing, remaining, tc.want)
+		}
+		lines := logLines(t, b)
+		if _, ok := lines[0]["grpc.applied_timeout_ms"]; ok != tc.applied {
+			t.Errorf("%s with %v: got applied timeout field %v, want %v", tc.header, tc.existing, ok, tc.applied)
+		}
+	}
+}
+
+func TestDebugHeaderRequiresSecret(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  []string
+	}{
+		{"s3cret", []string{"payload received", "payload sent"}},
+		{"guess", nil},
+		{"", nil},
+	} {
+		b := &bytes.Buffer{}
+		i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(), grpc_zerolog.WithDebugHeader("X-Debug", "s3cret"))
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-debug", tc.value))
+		_, _ = i(ctx, "request", &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "response", nil
+		})
+		if got := messages(logLines(t, b)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("header %q: got events %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestProviderDisabledLoggerOptsOut(t *testing.T) {
+	b := &bytes.Buffer{}
+	provider := func(ctx context.Context, fullMethod string) zerolog.Logger {
+		if fullMethod == testMethod {
+			return zerolog.Nop()
+		}
+		return zerolog.New(b)
+	}
+	i := grpc_zerolog.UnaryServerInterceptorProvider(provider, grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall), grpc_zerolog.WithInstanceID("i-1"))
+	for _, method := range []string{testMethod, "/grpc_zerolog.test.TestService/Other"} {
+		called := false
+		_, _ = i(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return nil, nil
+		})
+		if !called {
+			t.Errorf("%s: the handler is not called", method)
+		}
+	}
+	lines := logLines(t, b)
+	if len(lines) != 1 || lines[0]["grpc.method"] != "Other" || lines[0]["grpc.instance"] != "i-1" {
+		t.Errorf("got lines %v, want only the call of the enabled logger with the constant fields", lines)
+	}
+}
+
+type recordingSpan struct {
+	events []string
+	attrs  []map[string]interface{}
+	code   codes.Code
+}
+
+func (s *recordingSpan) AddEvent(name string, attributes map[string]interface{}) {
+	s.events = append(s.events, name)
+	s.attrs = append(s.attrs, attributes)
+}
+
+func (s *recordingSpan) SetError(code codes.Code, message string) {
+	s.code = code
+}
+
+func TestSpanEvents(t *testing.T) {
+	span := &recordingSpan{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(&bytes.Buffer{}), grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall, grpc_zerolog.FinishCall),
+		grpc_zerolog.WithSpanEvents(func(ctx context.Context) (grpc_zerolog.Span, bool) { return span, true }))
+	callUnary(i, nil, status.Error(codes.NotFound, "no such item"))
+
+	if want := []string{"StartCall", "FinishCall"}; !reflect.DeepEqual(span.events, want) {
+		t.Fatalf("got span events %q, want %q", span.events, want)
+	}
+	if got := span.attrs[1]["grpc.code"]; got != "NotFound" {
+		t.Errorf("got grpc.code attribute %v, want NotFound", got)
+	}
+	if span.code != codes.NotFound {
+		t.Errorf("got span error %v, want NotFound", span.code)
+	}
+}
+
+func TestRequestIDFromTrace(t *testing.T) {
+	b := &bytes.Buffer{}
+	type traceKey struct{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+		grpc_zerolog.WithRequestIDFromTrace(func(ctx context.Context) (string, bool) {
+			id, ok := ctx.Value(traceKey{}).(string)
+			return id, ok
+		}))
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for _, ctx := range []context.Context{context.WithValue(context.Background(), traceKey{}, traceID), context.Background()} {
+		_, _ = i(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+	}
+	lines := logLines(t, b)
+	if got := lines[0]["grpc.request.id"]; got != traceID {
+		t.Errorf("got request ID %v, want the trace ID", got)
+	}
+	if got, _ := lines[1]["grpc.request.id"].(string); len(got) != len(traceID) || got == traceID {
+		t.Errorf("got request ID %q without the span, want a fresh ID", got)
+	}
+}
+
+func TestShortTraceID(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	extract := func(ctx context.Context) (string, bool) { return traceID, true }
+	for _, tc := range []struct {
+		chars int
+		want  interface{}
+	}{{8, traceID[:8]}, {64, traceID}, {0, nil}} {
+		b := &bytes.Buffer{}
+		i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+			grpc_zerolog.WithShortTraceID(extract, tc.chars))
+		_, _ = i(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		if got := logLines(t, b)[0]["trace_id_short"]; got != tc.want {
+			t.Errorf("chars %d: got %v, want %v", tc.chars, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"github.com/pereslava/grpc_zerolog/ctxzerolog"
+	"github.com/rs/zerolog"
+)
+
+const msgStreamMessage = "stream message"
+
+type messageLevelKey struct{}
+
+// LogMessage writes one log line with the given fields and all the fields of the call logger stored in ctx
+// by the interceptors (see ctxzerolog). It is meant for the stream handlers processing the messages in a loop
+// that want the per-message logs correlated with the call.
+// The stream server interceptor logs the line at the level of codes.OK, otherwise the InfoLevel is used.
+// It does nothing if ctx has no call logger.
+func LogMessage(ctx context.Context, fields map[string]interface{}) {
+	level := zerolog.InfoLevel
+	if l, ok := ctx.Value(messageLevelKey{}).(zerolog.Level); ok {
+		level = l
+	}
+	l := ctxzerolog.Get(ctx).Fields(fields).Logger()
+	l.WithLevel(level).Msg(msgStreamMessage)
+}
@@ -0,0 +1,73 @@
+package grpc_zerolog
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// NewLogfmtLogger returns a zerolog.Logger with timestamp that writes to w in logfmt format (key=value pairs),
+// e.g.
+//
+//	time=2021-01-01T00:00:00Z level=info msg="finished unary call" grpc.code=OK grpc.method=Get grpc.service=orders.OrderService
+//
+// All the fields are flat, the fields are sorted by name except the "error" field that goes first.
+// The nested objects (e.g. payload content or "grpc.start") are rendered as the quoted compact JSON string.
+func NewLogfmtLogger(w io.Writer) zerolog.Logger {
+	return zerolog.New(zerolog.ConsoleWriter{
+		Out:     w,
+		NoColor: true,
+		FormatTimestamp: func(i interface{}) string {
+			return logfmtPart(zerolog.TimestampFieldName, i)
+		},
+		FormatLevel: func(i interface{}) string {
+			return logfmtPart(zerolog.LevelFieldName, i)
+		},
+		FormatCaller: func(i interface{}) string {
+			return logfmtPart(zerolog.CallerFieldName, i)
+		},
+		FormatMessage: func(i interface{}) string {
+			return logfmtPart("msg", i)
+		},
+		FormatFieldName: func(i interface{}) string {
+			return fmt.Sprintf("%s=", i)
+		},
+		FormatFieldValue: logfmtValue,
+		FormatErrFieldName: func(i interface{}) string {
+			return fmt.Sprintf("%s=", i)
+		},
+		FormatErrFieldValue: logfmtValue,
+	}).With().Timestamp().Logger()
+}
+
+func logfmtPart(name string, i interface{}) string {
+	if i == nil {
+		return ""
+	}
+	s := fmt.Sprint(i)
+	if s == "" {
+		return ""
+	}
+	return name + "=" + logfmtQuote(s)
+}
+
+// logfmtValue formats the field value, the ConsoleWriter already quotes the strings when needed,
+// the other values are passed as JSON
+func logfmtValue(i interface{}) string {
+	switch v := i.(type) {
+	case []byte:
+		return logfmtQuote(string(v))
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func logfmtQuote(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"{}[]\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
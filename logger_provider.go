@@ -0,0 +1,46 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// LoggerProvider function returns the base logger of the call.
+// It is called once per call before anything is logged, so it must be cheap, e.g. a lookup of the prepared
+// loggers rather than building a new one. The logger with zerolog.Disabled level opts the call out of the logging.
+type LoggerProvider func(ctx context.Context, fullMethod string) zerolog.Logger
+
+// fixedLogger returns the provider of the logger given to the constructor, decorated once by the options
+func (o *options) fixedLogger(logger zerolog.Logger) LoggerProvider {
+	logger = o.baseLogger(logger)
+	return func(context.Context, string) zerolog.Logger {
+		return logger
+	}
+}
+
+// decorateProvider returns the provider of the loggers decorated per call by the output and the constant fields
+// rendered once by evaluateOptions
+func (o *options) decorateProvider(p LoggerProvider) LoggerProvider {
+	return func(ctx context.Context, fullMethod string) zerolog.Logger {
+		l := p(ctx, fullMethod)
+		if l.GetLevel() == zerolog.Disabled {
+			return l
+		}
+		return o.baseLogger(l)
+	}
+}
+
+// optedOut reports whether the call is not handled by the interceptor at all because the logger is disabled.
+// The calls are still handled, only without the log lines, if an option changes the behavior of the call
+// (the panic recovery, the derived deadline, the context hooks) or observes it regardless of the logging
+// (the summaries and the summary collector).
+func (o *options) optedOut(logger zerolog.Logger) bool {
+	return logger.GetLevel() == zerolog.Disabled && !o.handlesSilentCalls()
+}
+
+// handlesSilentCalls reports whether the options must run for the calls with the disabled logger
+func (o *options) handlesSilentCalls() bool {
+	return o.recoverPanics || o.summary != nil || o.periodicSummary != nil || o.summaryCollector != nil ||
+		o.derivedDeadlineHeader != "" || o.onStart != nil || o.streamContextModifier != nil
+}
@@ -0,0 +1,42 @@
+package grpc_zerolog
+
+import (
+	"path"
+	"strings"
+)
+
+// Matcher function reports whether the gRPC method with given full method name (e.g. "/package.Service/Method") matches
+type Matcher func(fullMethodName string) bool
+
+// MatchMethods returns a Matcher that matches the given full method names
+func MatchMethods(fullMethodNames ...string) Matcher {
+	set := make(map[string]struct{}, len(fullMethodNames))
+	for _, m := range fullMethodNames {
+		set[m] = struct{}{}
+	}
+	return func(fullMethodName string) bool {
+		_, ok := set[fullMethodName]
+		return ok
+	}
+}
+
+// MatchServices returns a Matcher that matches all methods of the given services (e.g. "package.Service")
+func MatchServices(services ...string) Matcher {
+	set := make(map[string]struct{}, len(services))
+	for _, s := range services {
+		set[strings.TrimPrefix(s, "/")] = struct{}{}
+	}
+	return func(fullMethodName string) bool {
+		_, ok := set[strings.TrimPrefix(path.Dir(fullMethodName), "/")]
+		return ok
+	}
+}
+
+// MatchPattern returns a Matcher that matches the full method names by the shell pattern as in path.Match,
+// e.g. "/grpc.health.v1.Health/*"
+func MatchPattern(pattern string) Matcher {
+	return func(fullMethodName string) bool {
+		ok, err := path.Match(pattern, fullMethodName)
+		return err == nil && ok
+	}
+}
@@ -0,0 +1,53 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+// MessageProducer function produces the FinishCall log line.
+// The log contains all the fields of the call, the level is the result of the CodeToLevel function
+// and opts is the read-only view of the interceptor options.
+type MessageProducer func(ctx context.Context, log zerolog.Context, msg string, level zerolog.Level, code codes.Code, err error, opts CallOptions)
+
+// DefaultMessageProducer writes the log line with the given level and message
+func DefaultMessageProducer(ctx context.Context, log zerolog.Context, msg string, level zerolog.Level, code codes.Code, err error, opts CallOptions) {
+	l := log.Logger()
+	l.WithLevel(level).Msg(msg)
+}
+
+// WithMessageProducer customizes the function producing the FinishCall log line
+func WithMessageProducer(f MessageProducer) Option {
+	return func(o *options) {
+		o.messageProducer = f
+		o.customMessageProducer = true
+	}
+}
+
+// CallOptions is the read-only view of the effective options of the interceptor
+type CallOptions struct {
+	o *options
+}
+
+// LogsOn reports whether the interceptor logs on the event
+func (c CallOptions) LogsOn(e LoggableEvent) bool {
+	return c.o.hasEvent(e)
+}
+
+// LoggableEvents returns the copy of the events the interceptor logs on
+func (c CallOptions) LoggableEvents() []LoggableEvent {
+	return append([]LoggableEvent(nil), c.o.loggableEvents...)
+}
+
+// Levels returns the mapping function between gRPC return codes and log levels in use
+func (c CallOptions) Levels() CodeToLevel {
+	return c.o.levelFunc
+}
+
+// Decide reports whether the interceptor logs the call of the method finished with err, as decided by
+// WithSkipMethods and then by WithContextDecider, or WithDecider if it is not set
+func (c CallOptions) Decide(ctx context.Context, fullMethod string, err error) bool {
+	return c.o.decide(ctx, fullMethod, err)
+}
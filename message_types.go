@@ -0,0 +1,44 @@
+package grpc_zerolog
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+)
+
+// WithMessageTypeFields adds the proto message names of the request and the response, e.g. "mycompany.orders.v1.GetRequest",
+// as "grpc.request.type" and "grpc.response.type" fields of the FinishCall event without logging the content.
+// The streams log the names of the first messages, the fields are omitted if no proto message was sent or received.
+func WithMessageTypeFields() Option {
+	return func(o *options) {
+		o.messageTypeFields = true
+	}
+}
+
+// recordMessageType keeps the name of the first request or response message of the call
+func (o *options) recordMessageType(ci *callInfo, sent bool, m interface{}) {
+	if !o.messageTypeFields {
+		return
+	}
+	v := &ci.responseType
+	if sent != ci.server {
+		v = &ci.requestType
+	}
+	if v.Load() != nil {
+		return
+	}
+	if p, ok := m.(proto.Message); ok {
+		if name := proto.MessageName(p); name != "" {
+			v.Store(name)
+		}
+	}
+}
+
+func withMessageTypes(ci *callInfo, with zerolog.Context) zerolog.Context {
+	if name, ok := ci.requestType.Load().(string); ok {
+		with = with.Str("grpc.request.type", name)
+	}
+	if name, ok := ci.responseType.Load().(string); ok {
+		with = with.Str("grpc.response.type", name)
+	}
+	return with
+}
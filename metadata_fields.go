@@ -0,0 +1,262 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+// withIncomingMetadataFields adds the fields taken from the incoming metadata of the server call
+func (o *options) withIncomingMetadataFields(ctx context.Context, with zerolog.Context) zerolog.Context {
+	if !o.grpcTimeoutField && !o.gatewayFields && o.priorityHeader == "" && o.retryCountHeader == "" && !o.metadataSizeFields &&
+		len(o.fingerprintHeaders) == 0 && o.queueTimeHeader == "" && o.logicalMethodHeader == "" {
+		return with
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return with
+	}
+	if o.metadataSizeFields {
+		with = withMetadataSize(md, with)
+	}
+	if o.grpcTimeoutField {
+		if v := md.Get("grpc-timeout"); len(v) > 0 {
+			with = with.Str("grpc.request.grpc_timeout", o.metadataValue("grpc-timeout", v[0]))
+		}
+	}
+	if o.priorityHeader != "" {
+		if v := md.Get(o.priorityHeader); len(v) > 0 {
+			with = with.Str("grpc.request.priority", o.metadataValue(o.priorityHeader, v[0]))
+		}
+	}
+	if o.logicalMethodHeader != "" {
+		if v := md.Get(o.logicalMethodHeader); len(v) > 0 {
+			with = with.Str("grpc.logical_method", o.metadataValue(o.logicalMethodHeader, v[0]))
+		}
+	}
+	if o.retryCountHeader != "" {
+		if v := md.Get(o.retryCountHeader); len(v) > 0 {
+			if n, err := strconv.Atoi(strings.TrimSpace(v[0])); err == nil {
+				with = with.Int("grpc.retry.count", n)
+			} else {
+				with = with.Str("grpc.retry.count", o.metadataValue(o.retryCountHeader, v[0]))
+			}
+		}
+	}
+	if o.gatewayFields {
+		with = o.withGatewayFields(md, with)
+	}
+	if len(o.fingerprintHeaders) > 0 {
+		with = o.withFingerprint(md, with)
+	}
+	if o.queueTimeHeader != "" {
+		with = o.withQueueTime(md, with)
+	}
+	return with
+}
+
+// WithQueueTimeField adds the time since the request was received by an upstream proxy, stamped in the given header
+// of the incoming metadata (e.g. "x-request-received-at"), as "grpc.queue_time_ms" field of the server interceptors.
+// The header is either an RFC 3339 time or the Unix time in seconds with an optional fraction, e.g. "1700000000.123".
+// The field is omitted if the header is absent or malformed.
+func WithQueueTimeField(header string) Option {
+	return func(o *options) {
+		o.queueTimeHeader = strings.ToLower(header)
+	}
+}
+
+func (o *options) withQueueTime(md metadata.MD, with zerolog.Context) zerolog.Context {
+	v := md.Get(o.queueTimeHeader)
+	if len(v) == 0 {
+		return with
+	}
+	receivedAt, ok := parseTimestamp(strings.TrimSpace(v[0]))
+	if !ok {
+		return with
+	}
+	return with.Dur("grpc.queue_time_ms", time.Since(receivedAt))
+}
+
+// parseTimestamp parses the RFC 3339 time or the Unix time in seconds with an optional fraction
+func parseTimestamp(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	secs, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(secs > 0) || math.IsInf(secs, 0) {
+		return time.Time{}, false
+	}
+	whole := int64(secs)
+	return time.Unix(whole, int64((secs-float64(whole))*float64(time.Second))), true
+}
+
+// WithFingerprintField adds the hash of the values of the given headers of the incoming metadata (e.g. the idempotency key)
+// as "grpc.request.fingerprint" field of the server interceptors, to find the duplicate requests across the retries.
+// The hash is the hex-encoded prefix of SHA-256 of the header names and values, so the same values always give
+// the same fingerprint. The field is omitted if none of the headers is present.
+func WithFingerprintField(headers ...string) Option {
+	return func(o *options) {
+		o.fingerprintHeaders = make([]string, len(headers))
+		for i, h := range headers {
+			o.fingerprintHeaders[i] = strings.ToLower(h)
+		}
+	}
+}
+
+func (o *options) withFingerprint(md metadata.MD, with zerolog.Context) zerolog.Context {
+	h := sha256.New()
+	present := false
+	for _, name := range o.fingerprintHeaders {
+		values := md.Get(name)
+		if len(values) == 0 {
+			continue
+		}
+		present = true
+		// the lengths keep the concatenation unambiguous
+		h.Write([]byte(strconv.Itoa(len(name)) + ":" + name))
+		for _, v := range values {
+			h.Write([]byte(strconv.Itoa(len(v)) + ":" + v))
+		}
+	}
+	if !present {
+		return with
+	}
+	return with.Str("grpc.request.fingerprint", hex.EncodeToString(h.Sum(nil)[:8]))
+}
+
+// WithMetadataSizeFields adds the number of the incoming metadata keys and the sum of the lengths of their keys and values
+// as "grpc.metadata.count" and "grpc.metadata.bytes" fields of the server interceptors, e.g. to detect the header-bombing clients
+func WithMetadataSizeFields() Option {
+	return func(o *options) {
+		o.metadataSizeFields = true
+	}
+}
+
+func withMetadataSize(md metadata.MD, with zerolog.Context) zerolog.Context {
+	size := 0
+	for k, values := range md {
+		for _, v := range values {
+			size += len(k) + len(v)
+		}
+	}
+	return with.Int("grpc.metadata.count", len(md)).Int("grpc.metadata.bytes", size)
+}
+
+// WithMaxMetadataValueLength truncates the metadata values logged by the interceptors to n runes followed by
+// the "…(+K bytes)" suffix with the number of the cut bytes. The binary ("-bin") values are truncated after
+// the base64 encoding. The default is 1024, zero means unlimited.
+func WithMaxMetadataValueLength(n int) Option {
+	return func(o *options) {
+		o.maxMetadataValueLength = n
+	}
+}
+
+// WithMetadataRedactors transforms the values of the metadata keys before they are logged by the interceptors,
+// e.g. to log that "authorization" is present without the secret:
+//
+//	grpc_zerolog.WithMetadataRedactors(map[string]func(string) string{
+//		"authorization": func(string) string { return "***" },
+//	})
+//
+// The keys are matched case-insensitively. The value returned by the redactor is logged as is even for
+// the binary ("-bin") keys, it is still truncated by WithMaxMetadataValueLength.
+func WithMetadataRedactors(redactors map[string]func(string) string) Option {
+	m := make(map[string]func(string) string, len(redactors))
+	for k, f := range redactors {
+		m[strings.ToLower(k)] = f
+	}
+	return func(o *options) {
+		if o.metadataRedactors == nil {
+			o.metadataRedactors = make(map[string]func(string) string, len(m))
+		}
+		for k, f := range m {
+			o.metadataRedactors[k] = f
+		}
+	}
+}
+
+// metadataValue renders the value of the metadata key for the log field
+func (o *options) metadataValue(key, v string) string {
+	if redact, ok := o.metadataRedactors[strings.ToLower(key)]; ok {
+		v = redact(v)
+	} else if strings.HasSuffix(key, "-bin") {
+		v = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	if cut := truncateRunes(v, o.maxMetadataValueLength, ""); len(cut) < len(v) {
+		v = cut + "…(+" + strconv.Itoa(len(v)-len(cut)) + " bytes)"
+	}
+	return o.truncateValue(v)
+}
+
+// gatewayMetadataKeys are the keys of the metadata set by grpc-gateway for the proxied HTTP requests
+var gatewayMetadataKeys = []string{
+	"grpcgateway-user-agent",
+	"grpcgateway-accept",
+	"grpcgateway-content-type",
+	"grpcgateway-referer",
+	"grpcgateway-origin",
+	"x-forwarded-for",
+	"x-forwarded-host",
+}
+
+// withGatewayFields adds the grpc-gateway metadata as "grpc.gateway.<name>" fields, the "grpcgateway-" prefix is stripped
+func (o *options) withGatewayFields(md metadata.MD, with zerolog.Context) zerolog.Context {
+	for _, k := range gatewayMetadataKeys {
+		v := md.Get(k)
+		if len(v) == 0 {
+			continue
+		}
+		name := "grpc.gateway." + strings.TrimPrefix(k, "grpcgateway-")
+		if len(v) == 1 {
+			with = with.Str(name, o.metadataValue(k, v[0]))
+			continue
+		}
+		values := make([]string, len(v))
+		for i := range v {
+			values[i] = o.metadataValue(k, v[i])
+		}
+		with = with.Strs(name, values)
+	}
+	return with
+}
+
+// WithHeaderCaptureAllowlist adds the given keys of the header metadata returned by the server to the FinishCall event
+// of the client interceptors as "grpc.header.<key>" fields, e.g. for the diagnostics sent by the server before
+// the response. The keys are matched case-insensitively, the absent keys are omitted and the binary ("-bin") values
+// are base64-encoded. The option may be given more than once, the keys are added up.
+func WithHeaderCaptureAllowlist(keys ...string) Option {
+	return func(o *options) {
+		for _, k := range keys {
+			o.headerCaptureKeys = append(o.headerCaptureKeys, strings.ToLower(k))
+		}
+	}
+}
+
+// withCapturedHeader adds the fields of WithHeaderCaptureAllowlist taken from the header of the client call
+func (o *options) withCapturedHeader(md metadata.MD, with zerolog.Context) zerolog.Context {
+	for _, k := range o.headerCaptureKeys {
+		v := md.Get(k)
+		if len(v) == 0 {
+			continue
+		}
+		name := "grpc.header." + k
+		if len(v) == 1 {
+			with = with.Str(name, o.metadataValue(k, v[0]))
+			continue
+		}
+		values := make([]string, len(v))
+		for i := range v {
+			values[i] = o.metadataValue(k, v[i])
+		}
+		with = with.Strs(name, values)
+	}
+	return with
+}
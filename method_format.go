@@ -0,0 +1,46 @@
+package grpc_zerolog
+
+import "strings"
+
+// WithMethodNameFormatter changes how the method of the call is logged in the "grpc.service", "grpc.method"
+// and "grpc.full_method" fields, e.g. WithMethodNameFormatter(StripPackage). The formatter is called once
+// when the call starts, the formatted name is split at the last "/" into the service and the method.
+// The deciders, matchers and hooks are given the original full method name.
+func WithMethodNameFormatter(f func(fullMethod string) string) Option {
+	return func(o *options) {
+		o.methodFormatter = f
+	}
+}
+
+// Identity returns the full method name as it is, it is the default formatter of WithMethodNameFormatter
+func Identity(fullMethod string) string {
+	return fullMethod
+}
+
+// StripLeadingSlash formats "/mycompany.orders.v1.OrderService/Get" as "mycompany.orders.v1.OrderService/Get"
+func StripLeadingSlash(fullMethod string) string {
+	return strings.TrimPrefix(fullMethod, "/")
+}
+
+// StripPackage formats "/mycompany.orders.v1.OrderService/Get" as "OrderService/Get"
+func StripPackage(fullMethod string) string {
+	service, method := splitMethod(fullMethod)
+	return shortServiceName(service) + "/" + method
+}
+
+// formatMethod returns the full method name to be logged
+func (o *options) formatMethod(fullMethod string) string {
+	if o.methodFormatter == nil {
+		return fullMethod
+	}
+	return o.methodFormatter(fullMethod)
+}
+
+// splitMethod splits the full method name into the service without the leading slash and the method
+func splitMethod(fullMethod string) (service, method string) {
+	i := strings.LastIndexByte(fullMethod, '/')
+	if i < 0 {
+		return "", fullMethod
+	}
+	return strings.TrimPrefix(fullMethod[:i], "/"), fullMethod[i+1:]
+}
@@ -1,7 +1,16 @@
 package grpc_zerolog
 
 import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
 
@@ -23,8 +32,40 @@ const (
 	// "grpc.response.content" (server) or "grpc.request.content" (client) field.
 	// NOTE: This can get quite verbose, especially for streaming calls, use with caution (e.g. debug only purposes).
 	PayloadSent
+	// StreamOpened is a loggable event representing the first message received by the stream call.
+	// Log line for this event includes the time since the start of the call in "grpc.stream.first_recv_latency_ms" field.
+	// It applies to the stream interceptors only.
+	StreamOpened
+	// PayloadSendFailed is a loggable event representing the failed SendMsg of the stream call.
+	// Log line for this event includes the index of the message in "grpc.stream.msg_index" field and the error.
+	PayloadSendFailed
+	// PayloadReceiveFailed is a loggable event representing the failed RecvMsg of the stream call, io.EOF is not a failure.
+	// Log line for this event includes the index of the message in "grpc.stream.msg_index" field and the error.
+	PayloadReceiveFailed
 )
 
+// String returns the name of the event
+func (e LoggableEvent) String() string {
+	switch e {
+	case StartCall:
+		return "StartCall"
+	case FinishCall:
+		return "FinishCall"
+	case PayloadReceived:
+		return "PayloadReceived"
+	case PayloadSent:
+		return "PayloadSent"
+	case StreamOpened:
+		return "StreamOpened"
+	case PayloadSendFailed:
+		return "PayloadSendFailed"
+	case PayloadReceiveFailed:
+		return "PayloadReceiveFailed"
+	default:
+		return fmt.Sprintf("LoggableEvent(%d)", uint(e))
+	}
+}
+
 var (
 	// DefaultCodeToLevelFunc is the default implementation code to level logic.
 	// returns Info on codes.OK and Error in all other cases
@@ -41,10 +82,23 @@ var (
 		return true
 	}
 
+	// DefaultServerEvents are the events logged by the server interceptors unless WithLogOnEvents is set
+	DefaultServerEvents = []LoggableEvent{StartCall, FinishCall}
+
+	// DefaultClientEvents are the events logged by the client interceptors unless WithLogOnEvents is set,
+	// the start of the client calls is rarely useful
+	DefaultClientEvents = []LoggableEvent{FinishCall}
+
 	defaultOptions = &options{
-		levelFunc:      DefaultCodeToLevelFunc,
-		shouldLog:      DefaultDeciderFunc,
-		loggableEvents: []LoggableEvent{StartCall, FinishCall},
+		levelFunc:              DefaultCodeToLevelFunc,
+		durationFunc:           DefaultDurationToField,
+		messageProducer:        DefaultMessageProducer,
+		payloadSampleRate:      1,
+		inFlightLevel:          zerolog.InfoLevel,
+		maxMetadataValueLength: 1024,
+		debugHeaderLevel:       zerolog.DebugLevel,
+		streamFailureLevel:     zerolog.WarnLevel,
+		shouldLog:              DefaultDeciderFunc,
 	}
 )
 
@@ -54,6 +108,10 @@ type CodeToLevel func(code codes.Code) zerolog.Level
 // Decider function defines rules for suppressing any interceptor logs
 type Decider func(fullMethodName string, err error) bool
 
+// DeciderWithContext function defines rules for suppressing any interceptor logs with access to the call context.
+// The context is the incoming context on the server and the outgoing context on the client
+type DeciderWithContext func(ctx context.Context, fullMethodName string, err error) bool
+
 // Option used to configure the interceptors
 type Option func(*options)
 
@@ -64,6 +122,50 @@ func WithLevels(f CodeToLevel) Option {
 	}
 }
 
+// WithCodeLevels overlays the given levels on top of the current mapping of gRPC return codes to log levels.
+// The codes not present in the map use the mapping set by DefaultCodeToLevelFunc or by WithLevels earlier in the option list.
+// It panics if the map contains an invalid zerolog level.
+func WithCodeLevels(m map[codes.Code]zerolog.Level) Option {
+	levels := make(map[codes.Code]zerolog.Level, len(m))
+	for code, level := range m {
+		if level < zerolog.TraceLevel || level > zerolog.Disabled {
+			panic(fmt.Sprintf("grpc_zerolog: invalid level %d for code %s", level, code))
+		}
+		levels[code] = level
+	}
+	return func(o *options) {
+		next := o.levelFunc
+		o.levelFunc = func(code codes.Code) zerolog.Level {
+			if level, ok := levels[code]; ok {
+				return level
+			}
+			return next(code)
+		}
+	}
+}
+
+// WithCodeToLevelOverrides is the same as WithCodeLevels, e.g.
+//
+//	grpc_zerolog.WithCodeToLevelOverrides(map[codes.Code]zerolog.Level{codes.NotFound: zerolog.WarnLevel})
+func WithCodeToLevelOverrides(m map[codes.Code]zerolog.Level) Option {
+	return WithCodeLevels(m)
+}
+
+// WithDurationField customizes the function for adding the call duration to the log line
+func WithDurationField(f DurationToField) Option {
+	return func(o *options) {
+		o.durationFunc = f
+	}
+}
+
+// WithDurationRounding rounds the logged call duration to the multiple of d (e.g. time.Millisecond) before it's
+// added by the DurationToField function. Zero means no rounding, it is the default.
+func WithDurationRounding(d time.Duration) Option {
+	return func(o *options) {
+		o.durationRounding = d
+	}
+}
+
 // WithDecider customizes the function for deciding if the gRPC interceptor logs should log depends on fullMethodName and error from handler
 func WithDecider(f Decider) Option {
 	return func(o *options) {
@@ -71,24 +173,523 @@ func WithDecider(f Decider) Option {
 	}
 }
 
-// WithLogOnEvents customizes on what events the gRPC interceptor should log on.
+// WithSkipMethods suppresses all the logs of the methods matched by m regardless of the decider.
+// It can be given more than once, a method matched by any of the matchers is skipped.
+func WithSkipMethods(m Matcher) Option {
+	return func(o *options) {
+		o.skippedMethods = append(o.skippedMethods, m)
+	}
+}
+
+// WithContextDecider customizes the function for deciding if the gRPC interceptor logs should log depends on
+// the call context, fullMethodName and error from handler. It takes precedence over the decider set by WithDecider.
+func WithContextDecider(f DeciderWithContext) Option {
+	return func(o *options) {
+		o.contextDecider = f
+	}
+}
+
+// WithLogOnEvents customizes on what events the gRPC interceptor should log on,
+// the defaults are DefaultServerEvents and DefaultClientEvents.
 func WithLogOnEvents(events ...LoggableEvent) Option {
 	return func(o *options) {
-		o.loggableEvents = events
+		o.loggableEvents = append([]LoggableEvent(nil), events...)
+	}
+}
+
+// WithEventFilter calls f before each event is logged, the event is skipped if f returns false. The code is the code
+// of the finished call for FinishCall, of the failed message for PayloadSendFailed and PayloadReceiveFailed and
+// codes.OK otherwise. E.g. skip StartCall of all the methods:
+//
+//	grpc_zerolog.WithEventFilter(func(event grpc_zerolog.LoggableEvent, fullMethod string, code codes.Code) bool {
+//		return event != grpc_zerolog.StartCall
+//	})
+//
+// It is called only for the events enabled by WithLogOnEvents and the calls chosen by the decider.
+func WithEventFilter(f func(event LoggableEvent, fullMethod string, code codes.Code) bool) Option {
+	return func(o *options) {
+		o.eventFilter = f
+	}
+}
+
+// filterEvent reports whether the event passes the filter of WithEventFilter
+func (o *options) filterEvent(event LoggableEvent, fullMethod string, code codes.Code) bool {
+	return o.eventFilter == nil || o.eventFilter(event, fullMethod, code)
+}
+
+// WithTLSPeerFields adds the subject CN and SANs of the verified TLS peer certificate
+// as "grpc.peer.cert.subject" and "grpc.peer.cert.sans" fields.
+// The fields are omitted when the connection isn't TLS or the peer has no certificate.
+func WithTLSPeerFields() Option {
+	return func(o *options) {
+		o.tlsPeerFields = true
+	}
+}
+
+// WithProcessFields adds the "host" and "pid" fields to every log line of the interceptor.
+// The fields are added once to the base logger, so there is no per-call cost.
+// The "host" field is omitted if the hostname can't be resolved.
+func WithProcessFields() Option {
+	return func(o *options) {
+		o.processFields = true
+	}
+}
+
+// WithInstanceID adds the "grpc.instance" field with the given value to every log line of the interceptor.
+// The empty id is detected from the GRPC_ZEROLOG_INSTANCE_ID or POD_NAME environment variables, then from the hostname
+// (e.g. the pod name on Kubernetes), the field is omitted if it is unknown.
+func WithInstanceID(id string) Option {
+	return func(o *options) {
+		if id == "" {
+			id = detectInstanceID()
+		}
+		o.instanceID = id
+	}
+}
+
+// WithLoggerName adds the "grpc.logger" field with the given name to every log line of the interceptor,
+// to tell apart the lines of several logging interceptors of the same chain. The empty name omits the field, it is the default.
+func WithLoggerName(name string) Option {
+	return func(o *options) {
+		o.loggerName = name
+	}
+}
+
+// WithDeferredEmission holds the StartCall event of the call and emits it together with the FinishCall event
+// as a single log line, the start info is added as nested "grpc.start" object.
+// This halves the writes to the log for each call. If the call panics the StartCall event is still emitted.
+func WithDeferredEmission() Option {
+	return func(o *options) {
+		o.deferredEmission = true
+	}
+}
+
+// WithMinDurationToLog omits the FinishCall event of the successful calls faster than d, e.g. the cache hits.
+// The failed calls are always logged. With WithDeferredEmission the StartCall event of the omitted calls is omitted too.
+// Zero d disables it.
+func WithMinDurationToLog(d time.Duration) Option {
+	return func(o *options) {
+		o.minDurationToLog = d
+	}
+}
+
+// WithStreamFailureLevel sets the level of the PayloadSendFailed and PayloadReceiveFailed events, Warn by default
+func WithStreamFailureLevel(level zerolog.Level) Option {
+	return func(o *options) {
+		o.streamFailureLevel = level
+	}
+}
+
+// WithPerMessageSizeLogging makes the stream server interceptor log the serialized size of each received
+// proto message as "grpc.stream.recv_size" at Debug. Nothing is computed if the logger doesn't log Debug.
+func WithPerMessageSizeLogging() Option {
+	return func(o *options) {
+		o.perMessageSize = true
+	}
+}
+
+// WithOnStart calls f at the start of every call, e.g. to start a timer or a span. The returned context is used
+// for the rest of the call (the handler or invoker and the finish), nil keeps the original context.
+func WithOnStart(f func(ctx context.Context, fullMethod string) context.Context) Option {
+	return func(o *options) {
+		o.onStart = f
+	}
+}
+
+// WithServerStreamContextModifier customizes the context of the stream passed to the stream server handler.
+// The function is called before the handler is invoked with the context that already contains the logger,
+// the returned context is returned by stream.Context(). The nil context returned is ignored.
+func WithServerStreamContextModifier(f func(ctx context.Context, info *grpc.StreamServerInfo) context.Context) Option {
+	return func(o *options) {
+		o.streamContextModifier = f
+	}
+}
+
+// WithShortServiceNames strips the proto package from the "grpc.service" field,
+// so "mycompany.orders.v1.OrderService" is logged as "OrderService"
+func WithShortServiceNames() Option {
+	return func(o *options) {
+		o.shortServiceNames = true
+	}
+}
+
+// WithFullMethodField adds the full method name (e.g. "/mycompany.orders.v1.OrderService/Get") as "grpc.full_method" field
+func WithFullMethodField() Option {
+	return func(o *options) {
+		o.fullMethodField = true
+	}
+}
+
+// WithPayloadOnError logs the request content as "grpc.request.content" field of the FinishCall event if the call returns error.
+// The request of unary calls is retained until the call finishes, for the streams only the first 10 requests (the messages
+// received by the server streams, sent by the client streams) are retained.
+func WithPayloadOnError() Option {
+	return func(o *options) {
+		o.payloadOnError = true
+	}
+}
+
+// WithPayloadOnCodes logs the request and response content as "grpc.request.content" and "grpc.response.content" fields
+// of the FinishCall event if the call finishes with one of the given codes. The messages are retained like by WithPayloadOnError
+// and serialized only if the code matches.
+func WithPayloadOnCodes(c ...codes.Code) Option {
+	return func(o *options) {
+		o.payloadOnCodes = make(map[codes.Code]bool, len(c))
+		for _, code := range c {
+			o.payloadOnCodes[code] = true
+		}
+	}
+}
+
+// WithDeadlineRemaining adds the time left until the call deadline at the call finish as "grpc.deadline_remaining_ms" field.
+// The value is negative if the call overran the deadline. The field is omitted if the call has no deadline.
+func WithDeadlineRemaining() Option {
+	return func(o *options) {
+		o.deadlineRemaining = true
+	}
+}
+
+// WithGRPCTimeoutField adds the raw "grpc-timeout" header value of the incoming metadata as "grpc.request.grpc_timeout" field
+// of the server interceptors. The field is omitted if the header is absent.
+// NOTE: the grpc-go server transport consumes the "grpc-timeout" header to set the call deadline, so the header is
+// present in the metadata only if it was forwarded there by other means (e.g. a proxy or in-process transport).
+func WithGRPCTimeoutField() Option {
+	return func(o *options) {
+		o.grpcTimeoutField = true
+	}
+}
+
+// WithHandlerInfo adds the name of the Go method of the service implementation that served the call
+// as "grpc.handler" field of the server FinishCall event, e.g. "main.(*orderServer).Get".
+// The name is resolved once per method, the field is omitted if it can't be resolved.
+func WithHandlerInfo() Option {
+	return func(o *options) {
+		o.handlerNames = &handlerNames{}
+	}
+}
+
+// WithHandlerDurationField adds the duration of the handler invocation alone as "grpc.handler_duration_ms" field
+// of the server FinishCall event, unlike the call duration it excludes the interceptors chained before the handler.
+// For the streams it is the duration of the stream handler, i.e. the whole stream lifetime.
+func WithHandlerDurationField() Option {
+	return func(o *options) {
+		o.handlerDurationField = true
+	}
+}
+
+// WithHandlerInvokedField adds "grpc.handler_invoked" field to the server FinishCall event, it is true if the interceptor
+// called the next handler of the chain and false if the call failed in the interceptor before, e.g. by a panic
+// of the logging recovered by WithPanicHandler. The errors of the interceptors chained after this one
+// are returned by the handler, so they are logged with "grpc.handler_invoked":true.
+func WithHandlerInvokedField() Option {
+	return func(o *options) {
+		o.handlerInvokedField = true
+	}
+}
+
+// WithHashedPeerField adds the pseudonym of the peer IP as "grpc.peer.hash" field instead of the raw IP.
+// The pseudonym is the hex of the first 8 bytes of the SHA-256 hash of the salt and the IP, so it is stable for the same salt.
+// The field is omitted if the peer is not available.
+func WithHashedPeerField(salt string) Option {
+	return func(o *options) {
+		o.hashedPeer = true
+		o.peerHashSalt = salt
+	}
+}
+
+// WithStructuredStatus adds the status of the failed call as "grpc.status" object of the FinishCall event, e.g.
+//
+//	"grpc.status": {"code": "NOT_FOUND", "code_int": 5, "message": "user 123 not found"}
+//
+// Use WithoutErrorField to omit the error field duplicating the status message.
+func WithStructuredStatus() Option {
+	return func(o *options) {
+		o.structuredStatus = true
+	}
+}
+
+// WithoutErrorField omits the error field of the FinishCall event
+func WithoutErrorField() Option {
+	return func(o *options) {
+		o.noErrorField = true
+	}
+}
+
+// WithCallCountField adds the number of the calls of the method since the interceptor creation
+// as "grpc.method.count" field of the FinishCall event
+func WithCallCountField() Option {
+	return func(o *options) {
+		o.callCounts = &methodCounters{}
+	}
+}
+
+// WithPanicHandler makes the server interceptors recover the panics of the handlers.
+// The recovered panic is mapped by f to the error returned to the client, nil f maps all panics to codes.Internal.
+// The panic value, stack and the mapped code are logged with the FinishCall event as "grpc.panic", "grpc.panic.stack" and "grpc.code".
+func WithPanicHandler(f func(p interface{}) error) Option {
+	return func(o *options) {
+		o.recoverPanics = true
+		o.panicHandler = f
+	}
+}
+
+// WithTimeToHeader adds the time from the start of the client call to the response header as "grpc.time_to_header_ms"
+// field of the FinishCall event, to separate the connection and queueing latency from the server processing and the body streaming.
+// It requires the stats handler returned by NewClientStatsHandler registered on the connection,
+// the field is omitted if the header is not received (e.g. the call failed immediately).
+func WithTimeToHeader() Option {
+	return func(o *options) {
+		o.timeToHeader = true
 	}
 }
 
+// WithCompressionRatio adds the ratio of the wire size to the uncompressed size of the response
+// as "grpc.response.compression_ratio" field of the FinishCall event of the unary client calls.
+// It requires the stats handler returned by NewClientStatsHandler registered on the connection,
+// the field is omitted if the response is not compressed or the sizes are unknown.
+func WithCompressionRatio() Option {
+	return func(o *options) {
+		o.compressionRatio = true
+	}
+}
+
+// WithGatewayFields adds the metadata set by grpc-gateway for the proxied HTTP requests (e.g. "grpcgateway-user-agent",
+// "x-forwarded-for") as "grpc.gateway.<name>" fields of the server interceptors, e.g. "grpc.gateway.user-agent".
+// The absent keys are omitted.
+func WithGatewayFields() Option {
+	return func(o *options) {
+		o.gatewayFields = true
+	}
+}
+
+// WithPriorityField adds the value of the given header of the incoming metadata (e.g. "priority")
+// as "grpc.request.priority" field of the server interceptors. The field is omitted if the header is absent.
+func WithPriorityField(header string) Option {
+	return func(o *options) {
+		o.priorityHeader = strings.ToLower(header)
+	}
+}
+
+// WithRetryCountField adds the number of the retries set by the client in the given header of the incoming metadata
+// (e.g. "x-retry-count") as "grpc.retry.count" field of the server interceptors. The value that isn't an integer
+// is logged as the string. The field is omitted if the header is absent.
+func WithRetryCountField(header string) Option {
+	return func(o *options) {
+		o.retryCountHeader = strings.ToLower(header)
+	}
+}
+
+// WithLogicalMethodField adds the operation name carried in the given header of the incoming metadata
+// (e.g. "x-operation") as "grpc.logical_method" field of the server interceptors, e.g. for the generic dispatch methods
+// proxying many operations. The field is omitted if the header is absent, grpc.method is logged as usual.
+func WithLogicalMethodField(header string) Option {
+	return func(o *options) {
+		o.logicalMethodHeader = strings.ToLower(header)
+	}
+}
+
+// WithHTTPStatusField adds the HTTP status mapped from the gRPC code by CodeToHTTPStatus
+// as "grpc.http_status" field of the FinishCall event
+func WithHTTPStatusField() Option {
+	return WithHTTPStatusFunc(CodeToHTTPStatus)
+}
+
+// WithHTTPStatusFunc is like WithHTTPStatusField but maps the gRPC code to the HTTP status by f
+func WithHTTPStatusFunc(f func(code codes.Code) int) Option {
+	return func(o *options) {
+		o.httpStatusFunc = f
+	}
+}
+
+// WithCodeNameResolver makes f name the code of "grpc.code" field of the FinishCall event, e.g. to name
+// the custom codes outside of the standard range. The code is named by code.String() if f returns the empty string.
+func WithCodeNameResolver(f func(code codes.Code) string) Option {
+	return func(o *options) {
+		o.codeNameResolver = f
+	}
+}
+
+// codeName returns the name of the code logged as "grpc.code"
+func (o *options) codeName(code codes.Code) string {
+	if o.codeNameResolver != nil {
+		if name := o.codeNameResolver(code); name != "" {
+			return name
+		}
+	}
+	return code.String()
+}
+
 type options struct {
-	levelFunc      CodeToLevel
-	shouldLog      Decider
-	loggableEvents []LoggableEvent
+	levelFunc        CodeToLevel
+	durationFunc     DurationToField
+	durationRounding time.Duration
+	shouldLog        Decider
+	loggableEvents   []LoggableEvent
+	tlsPeerFields    bool
+
+	shortServiceNames bool
+	fullMethodField   bool
+	processFields     bool
+	instanceID        string
+
+	deferredEmission         bool
+	payloadOnError           bool
+	streamContextModifier    func(ctx context.Context, info *grpc.StreamServerInfo) context.Context
+	deadlineRemaining        bool
+	grpcTimeoutField         bool
+	summary                  *summaryReporter
+	sampler                  zerolog.Sampler
+	messageProducer          MessageProducer
+	output                   io.Writer
+	handlerNames             *handlerNames
+	hashedPeer               bool
+	peerHashSalt             string
+	structuredStatus         bool
+	noErrorField             bool
+	callCounts               *methodCounters
+	contextDecider           DeciderWithContext
+	recoverPanics            bool
+	panicHandler             func(p interface{}) error
+	payloadLogger            *zerolog.Logger
+	maxPayloadEvents         int64
+	payloadSampleRate        float64
+	payloadHash              crypto.Hash
+	payloadHashWithContent   bool
+	tagsExtractor            TagsExtractor
+	payloadFormatter         PayloadFormatter
+	bytesPayloadPrefix       int
+	buildInfo                bool
+	serviceName              string
+	serviceVersion           string
+	compressionRatio         bool
+	gatewayFields            bool
+	httpStatusFunc           func(code codes.Code) int
+	inFlightInterval         time.Duration
+	inFlightLevel            zerolog.Level
+	rootCauseField           bool
+	priorityHeader           string
+	cancelCauseField         bool
+	payloadOnCodes           map[codes.Code]bool
+	suppressedCodes          []suppressedCodes
+	skippedMethods           []Matcher
+	maxPayloadSize           int
+	sortedFields             bool
+	onStart                  func(ctx context.Context, fullMethod string) context.Context
+	fieldMasks               *fieldMasks
+	fieldMaskPassthrough     bool
+	maxFieldValueLength      int
+	maxMetadataValueLength   int
+	perMessageSize           bool
+	debugHeader              string
+	debugHeaderValue         string
+	debugHeaderLevel         zerolog.Level
+	publicErrorsOnly         bool
+	publicErrorCodes         map[codes.Code]bool
+	deadlineSkew             bool
+	deadlineSkewMargin       time.Duration
+	metadataSizeFields       bool
+	eventHook                EventHook
+	customMessageProducer    bool
+	streamFailureLevel       zerolog.Level
+	tlsField                 bool
+	payloadContentLevel      *zerolog.Level
+	timeToHeader             bool
+	methodFormatter          func(fullMethod string) string
+	handlerDurationField     bool
+	requestContentField      string
+	responseContentField     string
+	methodPayloads           map[string]bool // the payload events enabled per method by WithProtoOptions
+	inflightCount            *inflightCounter
+	streamByteTotals         bool
+	messageTypeFields        bool
+	metadataRedactors        map[string]func(string) string
+	skippedPayloadTypes      map[string]bool
+	eventFilter              func(event LoggableEvent, fullMethod string, code codes.Code) bool
+	retryCountHeader         string
+	flattenPayload           bool
+	handlerInvokedField      bool
+	costFunc                 CostFunction
+	suppressStreamPayloads   bool
+	codeNameResolver         func(code codes.Code) string
+	periodicSummary          *summaryReporter
+	periodicSummaryMethods   map[string]bool
+	methodCodeLevels         map[string]map[codes.Code]zerolog.Level
+	contextValueSpecs        []ContextValueSpec
+	protocolField            bool
+	errorOrigin              func(err error) string
+	eventLoggers             map[LoggableEvent]*zerolog.Logger
+	responseValidator        func(msg proto.Message) error
+	minDurationToLog         time.Duration
+	serviceConfigField       bool
+	errorGroup               func(err error) string
+	streamHeartbeat          time.Duration
+	compressionDecisionField bool
+	loggerName               string
+	derivedDeadlineHeader    string
+	finishOnFirstError       bool
+	deadlineLogging          bool
+	fingerprintHeaders       []string
+	errorBooleanOnly         bool
+	encodingMismatch         bool
+	queueTimeHeader          string
+	eventCallback            func(event LoggableEvent, fields map[string]interface{})
+	errorDedup               *errorDeduper
+	cacheHitKey              interface{}
+	summaryCollector         func(CallSummary)
+	burst                    *burstDetector
+	headerCaptureKeys        []string
+	contextLevel             func(ctx context.Context) (zerolog.Level, bool)
+	logicalMethodHeader      string
+	constantFields           []callField // the fields added by baseLogger, rendered once by evaluateOptions
+	spanExtractor            SpanExtractor
+	requestIDExtractor       TraceIDExtractor
+	shortTraceIDExtractor    TraceIDExtractor
+	shortTraceIDChars        int
 }
 
+// evaluateOptions evaluates the options of the server interceptors
 func evaluateOptions(opts []Option) *options {
+	return evaluateOptionsWithEvents(DefaultServerEvents, opts)
+}
+
+// evaluateClientOptions evaluates the options of the client interceptors
+func evaluateClientOptions(opts []Option) *options {
+	return evaluateOptionsWithEvents(DefaultClientEvents, opts)
+}
+
+func evaluateOptionsWithEvents(events []LoggableEvent, opts []Option) *options {
 	optCopy := &options{}
 	*optCopy = *defaultOptions
+	optCopy.loggableEvents = append([]LoggableEvent(nil), events...)
 	for _, o := range opts {
 		o(optCopy)
 	}
+	optCopy.constantFields = optCopy.renderConstantFields()
+	if optCopy.payloadLogger != nil {
+		l := optCopy.baseLogger(*optCopy.payloadLogger)
+		optCopy.payloadLogger = &l
+	}
+	optCopy.baseEventLoggers()
 	return optCopy
 }
+
+func (o *options) hasEvent(e LoggableEvent) bool {
+	for _, v := range o.loggableEvents {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *options) decide(ctx context.Context, fullMethod string, err error) bool {
+	for _, skip := range o.skippedMethods {
+		if skip(fullMethod) {
+			return false
+		}
+	}
+	if o.contextDecider != nil {
+		return o.contextDecider(ctx, fullMethod, err)
+	}
+	return o.shouldLog(fullMethod, err)
+}
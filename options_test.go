@@ -0,0 +1,26 @@
+package grpc_zerolog
+
+import (
+	"testing"
+)
+
+func TestEvaluateOptionsDoesNotShareLoggableEvents(t *testing.T) {
+	a := evaluateOptions(nil)
+	b := evaluateOptions(nil)
+	if &a.loggableEvents[0] == &b.loggableEvents[0] || &a.loggableEvents[0] == &DefaultServerEvents[0] {
+		t.Fatal("the loggable events share the backing array")
+	}
+
+	a.loggableEvents[0] = PayloadSent
+	a.loggableEvents = append(a.loggableEvents, PayloadReceived)
+	if b.loggableEvents[0] != StartCall || DefaultServerEvents[0] != StartCall {
+		t.Fatal("the change of the loggable events of one interceptor affects the others")
+	}
+
+	events := []LoggableEvent{FinishCall}
+	c := evaluateOptions([]Option{WithLogOnEvents(events...)})
+	events[0] = StartCall
+	if c.loggableEvents[0] != FinishCall {
+		t.Fatal("the loggable events share the backing array with WithLogOnEvents argument")
+	}
+}
@@ -0,0 +1,223 @@
+package grpc_zerolog
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	msgPayloadReceived message = "payload received"
+	msgPayloadSent     message = "payload sent"
+)
+
+// WithPayloadLogger makes the interceptors emit the PayloadReceived and PayloadSent events through the given logger
+// instead of the logger given to the constructor. The payload events still carry the same call fields,
+// so they can be joined with the other events of the call.
+func WithPayloadLogger(l zerolog.Logger) Option {
+	return func(o *options) {
+		o.payloadLogger = &l
+	}
+}
+
+func (o *options) logsPayloads() bool {
+	if o.hasEvent(PayloadReceived) || o.hasEvent(PayloadSent) {
+		return true
+	}
+	for _, enabled := range o.methodPayloads {
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMaxPayloadEventsPerCall limits the number of logged payload events per call and direction.
+// After n events a single "payload logging capped" line is logged and the rest of the payloads are not logged.
+// Zero means unlimited, it is the default.
+func WithMaxPayloadEventsPerCall(n int) Option {
+	return func(o *options) {
+		o.maxPayloadEvents = int64(n)
+	}
+}
+
+// WithPayloadSampleRate logs the payload events only for the given fraction (0.0-1.0) of the calls.
+// The decision is made once per call before any payload is serialized, the other events of the calls are not affected.
+func WithPayloadSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.payloadSampleRate = rate
+	}
+}
+
+func (o *options) samplePayloads() bool {
+	return o.payloadSampleRate >= 1 || (o.payloadSampleRate > 0 && rand.Float64() < o.payloadSampleRate)
+}
+
+// WithPayloadContentLevel marshals and logs the payload content only if the payload logger logs the given level,
+// e.g. zerolog.DebugLevel to log the payloads only when Debug is enabled without any serialization cost at Info.
+// The payload events without content are still logged if the payload hash is enabled.
+// It is the counterpart of WithPayloadLevel of the payload interceptors.
+func WithPayloadContentLevel(level zerolog.Level) Option {
+	return func(o *options) {
+		o.payloadContentLevel = &level
+	}
+}
+
+// logsPayloadContent reports whether the logger of the payload event logs the level set by WithPayloadContentLevel
+func (o *options) logsPayloadContent(ci *callInfo, event LoggableEvent) bool {
+	if o.payloadContentLevel == nil {
+		return true
+	}
+	level := *o.payloadContentLevel
+	return ci.eventLog(event).Logger().GetLevel() <= level && zerolog.GlobalLevel() <= level
+}
+
+// WithSuppressStreamPayloads disables the payload events of the stream calls, they are logged for the unary calls only.
+// It applies to the calls with the debug header of WithDebugHeader too, and the stream messages are not retained
+// by WithPayloadOnError and WithPayloadOnCodes. The other events of the streams are logged as usual.
+func WithSuppressStreamPayloads() Option {
+	return func(o *options) {
+		o.suppressStreamPayloads = true
+	}
+}
+
+// WithSkipPayloadTypes doesn't log the payloads of the given proto message types, e.g. "mycompany.files.v1.Chunk",
+// neither by the payload events nor by WithPayloadOnError and WithPayloadOnCodes. The messages are skipped before
+// any serialization, so it avoids the cost for the known large messages. The other events are logged as usual.
+func WithSkipPayloadTypes(typeNames ...string) Option {
+	return func(o *options) {
+		if o.skippedPayloadTypes == nil {
+			o.skippedPayloadTypes = make(map[string]bool, len(typeNames))
+		}
+		for _, name := range typeNames {
+			o.skippedPayloadTypes[name] = true
+		}
+	}
+}
+
+// skipsPayloadType reports whether the payload m is of a type skipped by WithSkipPayloadTypes
+func (o *options) skipsPayloadType(m interface{}) bool {
+	if o.skippedPayloadTypes == nil {
+		return false
+	}
+	p, ok := m.(proto.Message)
+	return ok && o.skippedPayloadTypes[proto.MessageName(p)]
+}
+
+// countPayloadEvent counts the payload event of the call and reports whether it is within the limit,
+// the cap marker is logged by the first event over the limit
+func (ci *callInfo) countPayloadEvent(event LoggableEvent, max int64, level zerolog.Level) bool {
+	counter := &ci.sentPayloads
+	if event == PayloadReceived {
+		counter = &ci.receivedPayloads
+	}
+	n := atomic.AddInt64(counter, 1)
+	if n <= max {
+		return true
+	}
+	if n == max+1 {
+		l := ci.eventLog(event).Logger()
+		l.WithLevel(level).Str("grpc.payload.event", event.String()).Int64("grpc.payload.max_events", max).
+			Msgf("payload logging capped after %d messages", max)
+	}
+	return false
+}
+
+// logPayload logs the PayloadReceived or PayloadSent event with the message content. The content field is
+// "grpc.request.content" for the received messages on the server and the sent messages on the client,
+// "grpc.response.content" otherwise, unless renamed by WithPayloadFieldNames.
+func (o *options) logPayload(ci *callInfo, event LoggableEvent, m interface{}) {
+	if ci.stream && o.suppressStreamPayloads {
+		return
+	}
+	enabled := ci.debugPayloads || (o.hasPayloadEvent(ci.fullMethod, event) && ci.sampled && ci.payloadSampled)
+	if !enabled || !o.decide(ci.ctx, ci.fullMethod, nil) || !o.filterEvent(event, ci.fullMethod, codes.OK) {
+		return
+	}
+	if !ci.debugPayloads && !o.logsPayloadContent(ci, event) && o.payloadHash == 0 {
+		return
+	}
+	if o.skipsPayloadType(m) {
+		return
+	}
+	if o.fieldMasks != nil {
+		var ok bool
+		if m, ok = o.maskPayload(ci, m); !ok {
+			return
+		}
+	}
+	logContent := m != nil && (o.payloadHash == 0 || o.payloadHashWithContent) && (ci.debugPayloads || o.logsPayloadContent(ci, event))
+	if !logContent && o.payloadHash == 0 {
+		return
+	}
+	level := o.levelFunc(codes.OK)
+	if ci.debugPayloads {
+		level = o.debugHeaderLevel
+	}
+	level = ci.eventLevel(level)
+	l := ci.eventLog(event).Logger()
+	e := l.WithLevel(level)
+	if e == nil {
+		return
+	}
+	if o.maxPayloadEvents > 0 && !ci.countPayloadEvent(event, o.maxPayloadEvents, level) {
+		return
+	}
+
+	var sum string
+	var renderPanic interface{}
+	if o.payloadHash != 0 {
+		renderPanic = recoverRenderPanic(ci.payloadLog, func() { sum = hashPayload(o.payloadHash, m) })
+	}
+	if !logContent && sum == "" && renderPanic == nil {
+		return
+	}
+
+	prefix, msg := "grpc.response", msgPayloadSent
+	if event == PayloadReceived {
+		msg = msgPayloadReceived
+	}
+	if (event == PayloadReceived) == ci.server {
+		prefix = "grpc.request"
+	}
+
+	if sum != "" {
+		e = e.Str(prefix+".hash", sum)
+	}
+	var content []callField // the content rendered once for the line and WithEventCallback
+	if logContent && renderPanic == nil {
+		var err error
+		renderPanic = recoverRenderPanic(ci.payloadLog, func() {
+			if !o.rendersEventFields(ci) {
+				e, err = o.renderPayload(e, prefix, m)
+				return
+			}
+			if content, err = o.renderPayloadFields(prefix, m); err == nil {
+				e = withRawFields(e, content)
+			}
+		})
+		if err != nil {
+			l.WithLevel(level).Err(err).Msg("failed to marshal payload")
+			return
+		}
+	}
+	if renderPanic != nil {
+		e = e.Str("grpc.payload.render_panic", fmt.Sprint(renderPanic))
+	}
+	o.runEventHook(ci, event, e)
+	e.Msg(string(msg))
+	o.runEventCallback(ci, event, ci.eventLog(event), level, msg, func(e *zerolog.Event) *zerolog.Event {
+		if sum != "" {
+			e = e.Str(prefix+".hash", sum)
+		}
+		e = withRawFields(e, content)
+		if renderPanic != nil {
+			e = e.Str("grpc.payload.render_panic", fmt.Sprint(renderPanic))
+		}
+		return e
+	})
+}
@@ -0,0 +1,77 @@
+package grpc_zerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/rs/zerolog"
+)
+
+// maxFlattenDepth is the nesting depth of the payload flattened by WithFlattenPayload,
+// the deeper values are logged as JSON
+const maxFlattenDepth = 10
+
+// WithFlattenPayload logs the JSON payload content of the payload events as the dotted fields instead of a nested object,
+// e.g. {"order":{"id":1},"items":[{"sku":"a"}]} as "grpc.request.content.order.id" and "grpc.request.content.items[0].sku".
+// The values nested deeper than 10 levels are logged as JSON, the empty objects and arrays are logged as is.
+// It costs the decoding of the marshaled payload, the content retained by WithPayloadOnError is not flattened.
+func WithFlattenPayload() Option {
+	return func(o *options) {
+		o.flattenPayload = true
+	}
+}
+
+// withFlattenedContent adds the JSON content b as the dotted fields under the key, the invalid JSON is added as is
+func withFlattenedContent(e *zerolog.Event, key string, b []byte) *zerolog.Event {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return e.RawJSON(key, b)
+	}
+	return flatten(e, key, v, 0)
+}
+
+func flatten(e *zerolog.Event, key string, v interface{}, depth int) *zerolog.Event {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 || depth >= maxFlattenDepth {
+			return withJSONValue(e, key, v)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e = flatten(e, key+"."+k, v[k], depth+1)
+		}
+		return e
+	case []interface{}:
+		if len(v) == 0 || depth >= maxFlattenDepth {
+			return withJSONValue(e, key, v)
+		}
+		for i, item := range v {
+			e = flatten(e, key+"["+strconv.Itoa(i)+"]", item, depth+1)
+		}
+		return e
+	case json.Number:
+		return e.RawJSON(key, []byte(v))
+	case string:
+		return e.Str(key, v)
+	case bool:
+		return e.Bool(key, v)
+	default:
+		return e.RawJSON(key, []byte("null"))
+	}
+}
+
+func withJSONValue(e *zerolog.Event, key string, v interface{}) *zerolog.Event {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return e
+	}
+	return e.RawJSON(key, b)
+}
@@ -0,0 +1,29 @@
+package grpc_zerolog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestFlattenedContent(t *testing.T) {
+	deep := strings.Repeat(`{"a":`, maxFlattenDepth+1) + "1" + strings.Repeat("}", maxFlattenDepth+1)
+	for _, tc := range []struct {
+		json string
+		want string
+	}{
+		{`{"order":{"id":1,"tags":[]},"items":[{"sku":"a"},[true]],"note":null}`,
+			`{"c.items[0].sku":"a","c.items[1][0]":true,"c.note":null,"c.order.id":1,"c.order.tags":[]}`},
+		{`{}`, `{"c":{}}`},
+		{deep, `{"c` + strings.Repeat(".a", maxFlattenDepth) + `":{"a":1}}`},
+	} {
+		b := &bytes.Buffer{}
+		l := zerolog.New(b)
+		withFlattenedContent(l.Log(), "c", []byte(tc.json)).Send()
+		if got := strings.TrimSpace(b.String()); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.json, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package grpc_zerolog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+)
+
+// maxFormattedPayloadLength is the maximal length of the payload formatted with %+v
+const maxFormattedPayloadLength = 4096
+
+// PayloadFormatter function renders the payload of custom type. It returns the field name and the value
+// to log, the empty field name means the default content field. If ok is false the default rendering is used.
+type PayloadFormatter func(m interface{}) (fieldName string, value interface{}, ok bool)
+
+// WithPayloadFormatter customizes the rendering of the payload content, f is called before the default rendering.
+//
+// By default proto.Message is marshaled as JSON by jsonpb, json.Marshaler and fmt.Stringer use their methods,
+// []byte logs its length as "<prefix>.content_length" (and the base64 prefix, see WithBytesPayloadPrefix),
+// other types are formatted with %+v and capped to 4096 bytes.
+func WithPayloadFormatter(f PayloadFormatter) Option {
+	return func(o *options) {
+		o.payloadFormatter = f
+	}
+}
+
+// WithBytesPayloadPrefix logs the base64 of the first n bytes of the []byte payloads as the content field
+func WithBytesPayloadPrefix(n int) Option {
+	return func(o *options) {
+		o.bytesPayloadPrefix = n
+	}
+}
+
+// WithMaxPayloadSize caps the rendered content of the payloads to n bytes, the longer content is logged truncated as a string
+// with "<prefix>.content_truncated" field set. Zero means no cap except the 4096 bytes of the payloads formatted with %+v, it is the default.
+func WithMaxPayloadSize(n int) Option {
+	return func(o *options) {
+		o.maxPayloadSize = n
+	}
+}
+
+// WithPayloadFieldNames renames the content fields of the request and response payloads, by default
+// "grpc.request.content" and "grpc.response.content". The "_length" and "_truncated" fields follow the new names,
+// e.g. "req_body_truncated". An empty name keeps the default one.
+func WithPayloadFieldNames(requestField, responseField string) Option {
+	return func(o *options) {
+		o.requestContentField = requestField
+		o.responseContentField = responseField
+	}
+}
+
+// contentField returns the name of the content field of the payload, prefix is "grpc.request" or "grpc.response"
+func (o *options) contentField(prefix string) string {
+	if prefix == "grpc.request" && o.requestContentField != "" {
+		return o.requestContentField
+	}
+	if prefix == "grpc.response" && o.responseContentField != "" {
+		return o.responseContentField
+	}
+	return prefix + ".content"
+}
+
+// withContent adds the rendered content as the key field, truncated to the size set by WithMaxPayloadSize
+func (o *options) withContent(e *zerolog.Event, key string, b []byte, raw bool) *zerolog.Event {
+	if o.maxPayloadSize > 0 && len(b) > o.maxPayloadSize {
+		return e.Str(key, truncateBytes(string(b), o.maxPayloadSize)).Bool(key+"_truncated", true)
+	}
+	if raw && o.flattenPayload {
+		return withFlattenedContent(e, key, b)
+	}
+	if raw {
+		return e.RawJSON(key, b)
+	}
+	return e.Str(key, string(b))
+}
+
+// renderPayload adds the content of the payload m to the event, prefix is "grpc.request" or "grpc.response"
+func (o *options) renderPayload(e *zerolog.Event, prefix string, m interface{}) (*zerolog.Event, error) {
+	key := o.contentField(prefix)
+	if o.payloadFormatter != nil {
+		if name, value, ok := o.payloadFormatter(m); ok {
+			if name == "" {
+				name = key
+			}
+			return e.Interface(name, value), nil
+		}
+	}
+
+	switch v := m.(type) {
+	case proto.Message:
+		b, err := (&jsonpbMarshalleble{v}).MarshalJSON()
+		if err != nil {
+			return e, err
+		}
+		return o.withContent(e, key, b, true), nil
+	case json.Marshaler:
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return e, err
+		}
+		return o.withContent(e, key, b, true), nil
+	case []byte:
+		e = e.Int(key+"_length", len(v))
+		if o.bytesPayloadPrefix > 0 {
+			n := o.bytesPayloadPrefix
+			if n > len(v) {
+				n = len(v)
+			}
+			e = e.Str(key, base64.StdEncoding.EncodeToString(v[:n]))
+		}
+		return e, nil
+	case fmt.Stringer:
+		return o.withContent(e, key, []byte(v.String()), false), nil
+	default:
+		s := fmt.Sprintf("%+v", v)
+		if len(s) > maxFormattedPayloadLength && (o.maxPayloadSize <= 0 || o.maxPayloadSize > maxFormattedPayloadLength) {
+			return e.Str(key, truncateBytes(s, maxFormattedPayloadLength)).Bool(key+"_truncated", true), nil
+		}
+		return o.withContent(e, key, []byte(s), false), nil
+	}
+}
+
+// maxRenderPanicStack is the maximal length of the stack logged for the panic of the payload rendering
+const maxRenderPanicStack = 2048
+
+// recoverRenderPanic calls the payload rendering f and recovers its panic (e.g. of a custom String method),
+// so the logging never fails the call. The panic is logged at Warn with the truncated stack and returned.
+func recoverRenderPanic(log zerolog.Context, f func()) (panicValue interface{}) {
+	defer func() {
+		if panicValue = recover(); panicValue == nil {
+			return
+		}
+		stack := debug.Stack()
+		if len(stack) > maxRenderPanicStack {
+			stack = stack[:maxRenderPanicStack]
+		}
+		l := log.Logger()
+		l.Warn().Str("grpc.panic", fmt.Sprint(panicValue)).Bytes("grpc.panic.stack", stack).Msg("payload rendering panicked")
+	}()
+	f()
+	return nil
+}
@@ -0,0 +1,64 @@
+package grpc_zerolog
+
+import (
+	"crypto"
+	_ "crypto/sha256" // the default payload hash
+	"encoding/hex"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	protov2 "google.golang.org/protobuf/proto"
+)
+
+// WithPayloadHash logs the lowercase hex hash of the payload as "grpc.request.hash" or "grpc.response.hash"
+// field of the payload events instead of the payload content. Zero h means crypto.SHA256.
+//
+// The proto messages are hashed over the deterministic serialization, that costs an additional marshaling
+// of each logged message. The []byte messages are hashed as is, the hash of other messages is omitted.
+// It panics if the hash function is not linked into the binary.
+func WithPayloadHash(h crypto.Hash) Option {
+	h = checkPayloadHash(h)
+	return func(o *options) {
+		o.payloadHash = h
+		o.payloadHashWithContent = false
+	}
+}
+
+// WithPayloadHashAndContent is like WithPayloadHash but logs the payload content as well
+func WithPayloadHashAndContent(h crypto.Hash) Option {
+	h = checkPayloadHash(h)
+	return func(o *options) {
+		o.payloadHash = h
+		o.payloadHashWithContent = true
+	}
+}
+
+func checkPayloadHash(h crypto.Hash) crypto.Hash {
+	if h == 0 {
+		h = crypto.SHA256
+	}
+	if !h.Available() {
+		panic(fmt.Sprintf("grpc_zerolog: payload hash function %d is not available", h))
+	}
+	return h
+}
+
+// hashPayload returns the hex hash of the message or empty string if the message can't be hashed
+func hashPayload(h crypto.Hash, m interface{}) string {
+	var b []byte
+	switch v := m.(type) {
+	case proto.Message:
+		var err error
+		b, err = protov2.MarshalOptions{Deterministic: true}.Marshal(proto.MessageV2(v))
+		if err != nil {
+			return ""
+		}
+	case []byte:
+		b = v
+	default:
+		return ""
+	}
+	hash := h.New()
+	hash.Write(b)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -97,8 +97,13 @@ type payloadMessage string
 
 func logProtoMessageAsJson(logger zerolog.Logger, level zerolog.Level, pbMsg interface{}, key payloadMessage) {
 	if p, ok := pbMsg.(proto.Message); ok {
-		m := &jsonpbMarshalleble{p}
-		json, err := m.MarshalJSON()
+		var json []byte
+		var err error
+		renderPanic := recoverRenderPanic(logger.With(), func() { json, err = (&jsonpbMarshalleble{p}).MarshalJSON() })
+		if renderPanic != nil {
+			logger.WithLevel(level).Str("grpc.payload.render_panic", fmt.Sprint(renderPanic)).Send()
+			return
+		}
 		if err != nil {
 			logger.WithLevel(level).Err(err).Msg("Failed to marshal message")
 		}
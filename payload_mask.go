@@ -0,0 +1,137 @@
+package grpc_zerolog
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WithPayloadFieldMask logs only the approved fields of the payloads. The masks are keyed by the full method name,
+// the value is the list of the field paths in the field mask syntax, e.g. "user.id" or "amount". The logged payload
+// is a new message with only these fields, the paths go through the nested and the repeated message fields.
+// The unknown paths are ignored with a one-time warning.
+//
+// The payloads of the methods without a mask and the non-proto payloads of the masked methods are not logged,
+// see WithPayloadFieldMaskPassthrough. The mask applies to the payload events and the content logged
+// by WithPayloadOnError and WithPayloadOnCodes.
+func WithPayloadFieldMask(masks map[string][]string) Option {
+	trees := make(map[string]maskTree, len(masks))
+	for method, paths := range masks {
+		trees[method] = newMaskTree(paths)
+	}
+	return func(o *options) {
+		o.fieldMasks = &fieldMasks{trees: trees}
+	}
+}
+
+// WithPayloadFieldMaskPassthrough makes the methods without a mask set by WithPayloadFieldMask log the payloads
+// unmasked instead of nothing
+func WithPayloadFieldMaskPassthrough() Option {
+	return func(o *options) {
+		o.fieldMaskPassthrough = true
+	}
+}
+
+// maskTree is the parsed field paths of a mask. The subtree selects the whole field if it is empty or has
+// the wholeField key, i.e. a path ends at it.
+type maskTree map[string]maskTree
+
+const wholeField = ""
+
+func newMaskTree(paths []string) maskTree {
+	tree := maskTree{}
+	for _, p := range paths {
+		node := tree
+		for _, name := range strings.Split(p, ".") {
+			next, ok := node[name]
+			if !ok {
+				next = maskTree{}
+				node[name] = next
+			}
+			node = next
+		}
+		node[wholeField] = nil
+	}
+	return tree
+}
+
+func (t maskTree) selectsWholeField() bool {
+	_, ok := t[wholeField]
+	return ok || len(t) == 0
+}
+
+type fieldMasks struct {
+	trees  map[string]maskTree
+	warned sync.Map // "<full method> <message type>.<field>" of the unknown paths already reported
+}
+
+// maskPayload returns the payload of the call to log and whether to log it at all
+func (o *options) maskPayload(ci *callInfo, m interface{}) (interface{}, bool) {
+	tree, ok := o.fieldMasks.trees[ci.fullMethod]
+	if !ok {
+		return m, o.fieldMaskPassthrough
+	}
+	p, ok := m.(proto.Message)
+	if !ok {
+		return nil, false
+	}
+	src := proto.MessageReflect(p)
+	dst := src.New()
+	o.fieldMasks.apply(ci, src, dst, tree)
+	return proto.MessageV1(dst.Interface()), true
+}
+
+// apply copies the fields of the mask tree from src to dst
+func (f *fieldMasks) apply(ci *callInfo, src, dst protoreflect.Message, tree maskTree) {
+	fields := src.Descriptor().Fields()
+	for name, sub := range tree {
+		if name == wholeField {
+			continue
+		}
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil || (!sub.selectsWholeField() && fd.Message() == nil) {
+			f.warnUnknown(ci, src.Descriptor(), name)
+			continue
+		}
+		if !src.Has(fd) {
+			continue
+		}
+		switch {
+		case sub.selectsWholeField():
+			dst.Set(fd, src.Get(fd))
+		case fd.IsList():
+			srcList, dstList := src.Get(fd).List(), dst.Mutable(fd).List()
+			for i := 0; i < srcList.Len(); i++ {
+				v := dstList.NewElement()
+				f.apply(ci, srcList.Get(i).Message(), v.Message(), sub)
+				dstList.Append(v)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				f.warnUnknown(ci, src.Descriptor(), name)
+				continue
+			}
+			dstMap := dst.Mutable(fd).Map()
+			src.Get(fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				dv := dstMap.NewValue()
+				f.apply(ci, v.Message(), dv.Message(), sub)
+				dstMap.Set(k, dv)
+				return true
+			})
+		default:
+			f.apply(ci, src.Get(fd).Message(), dst.Mutable(fd).Message(), sub)
+		}
+	}
+}
+
+func (f *fieldMasks) warnUnknown(ci *callInfo, md protoreflect.MessageDescriptor, name string) {
+	key := ci.fullMethod + " " + string(md.FullName()) + "." + name
+	if _, warned := f.warned.LoadOrStore(key, struct{}{}); warned {
+		return
+	}
+	l := ci.payloadLog.Logger()
+	l.Warn().Str("grpc.payload.mask_path", name).Str("grpc.payload.mask_message", string(md.FullName())).
+		Msg("unknown payload field mask path ignored")
+}
@@ -0,0 +1,117 @@
+package grpc_zerolog
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+// maxRetainedStreamMessages is the number of first stream messages retained by WithPayloadOnError and WithPayloadOnCodes
+const maxRetainedStreamMessages = 10
+
+// retainedMessages keeps the request or response messages until the call finishes
+type retainedMessages struct {
+	mu     sync.Mutex
+	max    int
+	key    string                                  // the field name of the content
+	filter func(m interface{}) (interface{}, bool) // masks the messages, nil keeps them as is
+	msgs   []proto.Message
+}
+
+func newRetainedMessages(max int, key string) *retainedMessages {
+	return &retainedMessages{max: max, key: key}
+}
+
+func (r *retainedMessages) add(m interface{}) {
+	if r.filter != nil {
+		var ok bool
+		if m, ok = r.filter(m); !ok {
+			return
+		}
+	}
+	p, ok := m.(proto.Message)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.msgs) >= r.max {
+		return
+	}
+	if r.max > 1 {
+		// the stream handler can reuse the message for the next RecvMsg
+		p = proto.Clone(p)
+	}
+	r.msgs = append(r.msgs, p)
+}
+
+// withContent adds the retained messages as the content field, a single message for unary calls,
+// an array of messages for streams
+func (r *retainedMessages) withContent(with zerolog.Context) zerolog.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.msgs) == 0 {
+		return with
+	}
+	if r.max == 1 {
+		json, err := (&jsonpbMarshalleble{r.msgs[0]}).MarshalJSON()
+		if err != nil {
+			return with
+		}
+		return with.RawJSON(r.key, json)
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteByte('[')
+	for i, m := range r.msgs {
+		json, err := (&jsonpbMarshalleble{m}).MarshalJSON()
+		if err != nil {
+			json = []byte("null")
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(json)
+	}
+	b.WriteByte(']')
+	return with.RawJSON(r.key, b.Bytes())
+}
+
+// retainsPayloads reports whether the messages have to be retained until the call finishes
+func (o *options) retainsPayloads() bool {
+	return o.payloadOnError || len(o.payloadOnCodes) > 0
+}
+
+// logsRetainedPayloads reports whether the retained messages are logged for the code of the finished call
+func (o *options) logsRetainedPayloads(code codes.Code) bool {
+	return (o.payloadOnError && code != codes.OK) || o.payloadOnCodes[code]
+}
+
+// retainCall prepares the retention of the messages of the call
+func (o *options) retainCall(ci *callInfo, max int) {
+	if !o.retainsPayloads() || (ci.stream && o.suppressStreamPayloads) {
+		return
+	}
+	ci.requests = newRetainedMessages(max, o.contentField("grpc.request"))
+	if len(o.payloadOnCodes) > 0 {
+		ci.responses = newRetainedMessages(max, o.contentField("grpc.response"))
+	}
+	if o.fieldMasks != nil || o.skippedPayloadTypes != nil {
+		filter := func(m interface{}) (interface{}, bool) {
+			if o.skipsPayloadType(m) {
+				return nil, false
+			}
+			if o.fieldMasks == nil {
+				return m, true
+			}
+			return o.maskPayload(ci, m)
+		}
+		ci.requests.filter = filter
+		if ci.responses != nil {
+			ci.responses.filter = filter
+		}
+	}
+}
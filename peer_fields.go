@@ -0,0 +1,98 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// withTLSPeerFields adds the subject CN and SANs of the verified peer certificate if the connection is TLS
+func withTLSPeerFields(ctx context.Context, with zerolog.Context) zerolog.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return with
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return with
+	}
+
+	leaf := info.State.VerifiedChains[0][0]
+	with = with.Str("grpc.peer.cert.subject", leaf.Subject.CommonName)
+
+	sans := make([]string, 0, len(leaf.DNSNames)+len(leaf.EmailAddresses)+len(leaf.IPAddresses)+len(leaf.URIs))
+	sans = append(sans, leaf.DNSNames...)
+	sans = append(sans, leaf.EmailAddresses...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, u := range leaf.URIs {
+		sans = append(sans, u.String())
+	}
+	if len(sans) > 0 {
+		with = with.Strs("grpc.peer.cert.sans", sans)
+	}
+	return with
+}
+
+// WithTLSField adds whether the connection of the call is TLS-encrypted as "grpc.request.tls" field, e.g. to spot
+// the plaintext calls in the environments that should be fully encrypted. The server interceptors add it when
+// the call starts, the client interceptors to the FinishCall event, it is omitted if the call failed before
+// the connection was chosen.
+func WithTLSField() Option {
+	return func(o *options) {
+		o.tlsField = true
+	}
+}
+
+// withTLSField adds whether the peer connection is TLS-encrypted, the field is omitted if the peer is unknown
+func withTLSField(p *peer.Peer, with zerolog.Context) zerolog.Context {
+	if p == nil || p.Addr == nil {
+		return with
+	}
+	_, tls := p.AuthInfo.(credentials.TLSInfo)
+	return with.Bool("grpc.request.tls", tls)
+}
+
+// WithProtocolField adds the application protocol negotiated by ALPN for the connection of the call
+// (e.g. "h2") as "grpc.protocol" field, the server and client interceptors add it like WithTLSField.
+// The field is omitted if the protocol isn't negotiated, e.g. for the plaintext connections.
+func WithProtocolField() Option {
+	return func(o *options) {
+		o.protocolField = true
+	}
+}
+
+// withProtocolField adds the protocol negotiated for the peer connection, the field is omitted if it is unknown
+func withProtocolField(p *peer.Peer, with zerolog.Context) zerolog.Context {
+	if p == nil {
+		return with
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || info.State.NegotiatedProtocol == "" {
+		return with
+	}
+	return with.Str("grpc.protocol", info.State.NegotiatedProtocol)
+}
+
+// withHashedPeerField adds the salted SHA-256 hash of the peer IP, so the peer can be grouped by without being identified
+func withHashedPeerField(ctx context.Context, with zerolog.Context, salt string) zerolog.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return with
+	}
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if ip == "" {
+		return with
+	}
+	h := sha256.Sum256([]byte(salt + ip))
+	return with.Str("grpc.peer.hash", hex.EncodeToString(h[:8]))
+}
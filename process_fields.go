@@ -0,0 +1,76 @@
+package grpc_zerolog
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	hostnameOnce sync.Once
+	hostname     string
+)
+
+func getHostname() string {
+	hostnameOnce.Do(func() {
+		if h, err := os.Hostname(); err == nil {
+			hostname = h
+		}
+	})
+	return hostname
+}
+
+// instanceIDEnv are the environment variables of the instance ID of WithInstanceID in the order of precedence
+var instanceIDEnv = []string{DefaultEnvPrefix + "_INSTANCE_ID", "POD_NAME"}
+
+// detectInstanceID returns the instance ID taken from the environment or the hostname, empty if it is unknown
+func detectInstanceID() string {
+	for _, name := range instanceIDEnv {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return getHostname()
+}
+
+// baseLogger adds the output and the constant fields enabled by options to the logger given to the interceptor constructor.
+// The constant fields are rendered once by evaluateOptions, so it is cheap enough for the loggers of LoggerProvider.
+func (o *options) baseLogger(logger zerolog.Logger) zerolog.Logger {
+	if o.output != nil {
+		logger = logger.Output(o.output)
+	}
+	if len(o.constantFields) == 0 {
+		return logger
+	}
+	with := logger.With()
+	for _, f := range o.constantFields {
+		with = with.RawJSON(f.key, f.value)
+	}
+	return with.Logger()
+}
+
+// renderConstantFields returns the fields of the build info, the process, the instance and the logger name
+func (o *options) renderConstantFields() []callField {
+	if !o.processFields && o.instanceID == "" && o.loggerName == "" && !o.buildInfo && o.serviceName == "" && o.serviceVersion == "" {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	with := o.withBuildFields(zerolog.New(buf).With())
+	if o.processFields {
+		if h := getHostname(); h != "" {
+			with = with.Str("host", h)
+		}
+		with = with.Int("pid", os.Getpid())
+	}
+	if o.instanceID != "" {
+		with = with.Str("grpc.instance", o.instanceID)
+	}
+	if o.loggerName != "" {
+		with = with.Str("grpc.logger", o.loggerName)
+	}
+	l := with.Logger()
+	l.Log().Msg("")
+	return decodeCallFields(buf.Bytes())
+}
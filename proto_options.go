@@ -0,0 +1,144 @@
+package grpc_zerolog
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// WithProtoOptions configures the logging of the methods by the custom method option ext declared next to the API,
+// e.g. see testdata/logging_options.proto:
+//
+//	message LoggingOptions {
+//		optional bool skip = 1;     // the calls of the method are not logged, like WithSkipMethods
+//		optional bool payloads = 2; // the payload events of the method are logged (true) or not (false)
+//		optional string level = 3;  // the level of the successful FinishCall events, e.g. "debug"
+//	}
+//
+//	extend google.protobuf.MethodOptions {
+//		LoggingOptions logging = 50000;
+//	}
+//
+//	rpc Check(CheckRequest) returns (CheckResponse) {
+//		option (mycompany.logging) = { skip: true };
+//	}
+//
+// The services registered in protoregistry.GlobalFiles are scanned once when the interceptor is created, so the
+// packages of the generated code must be imported before. The methods without the option use the global settings,
+// the unset fields of the option, the unknown fields and the invalid levels are ignored. The fields need the presence
+// (proto2 or proto3 optional) to tell "payloads: false" from the unset field.
+func WithProtoOptions(ext protoreflect.ExtensionType) Option {
+	return protoOptionsFrom(protoregistry.GlobalFiles, ext)
+}
+
+// methodProtoOptions is the logging configuration of a method read from its options
+type methodProtoOptions struct {
+	skip       bool
+	payloads   *bool
+	level      zerolog.Level
+	levelIsSet bool
+}
+
+func protoOptionsFrom(files *protoregistry.Files, ext protoreflect.ExtensionType) Option {
+	return func(o *options) {
+		methods := readProtoOptions(files, ext)
+		var skipped []string
+		for method, m := range methods {
+			if m.skip {
+				skipped = append(skipped, method)
+			}
+			if m.payloads != nil {
+				if o.methodPayloads == nil {
+					o.methodPayloads = make(map[string]bool)
+				}
+				o.methodPayloads[method] = *m.payloads
+			}
+			if m.levelIsSet {
+				WithSuppressedCodesMatching(MatchMethods(method), m.level, codes.OK)(o)
+			}
+		}
+		if len(skipped) > 0 {
+			o.skippedMethods = append(o.skippedMethods, MatchMethods(skipped...))
+		}
+	}
+}
+
+// readProtoOptions returns the configuration of the methods annotated with ext keyed by the full method name
+func readProtoOptions(files *protoregistry.Files, ext protoreflect.ExtensionType) map[string]methodProtoOptions {
+	xd := ext.TypeDescriptor()
+	if xd.Message() == nil || xd.ContainingMessage().FullName() != "google.protobuf.MethodOptions" {
+		return nil
+	}
+	// the options of the generated code are parsed without the extension types unknown to the global registry
+	resolver := &protoregistry.Types{}
+	if err := resolver.RegisterExtension(ext); err != nil {
+		return nil
+	}
+
+	methods := make(map[string]methodProtoOptions)
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			sd := services.Get(i)
+			for j := 0; j < sd.Methods().Len(); j++ {
+				md := sd.Methods().Get(j)
+				if m, ok := methodOptions(md, xd, resolver); ok {
+					methods["/"+string(sd.FullName())+"/"+string(md.Name())] = m
+				}
+			}
+		}
+		return true
+	})
+	return methods
+}
+
+func methodOptions(md protoreflect.MethodDescriptor, xd protoreflect.ExtensionTypeDescriptor, resolver *protoregistry.Types) (methodProtoOptions, bool) {
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return methodProtoOptions{}, false
+	}
+	b, err := proto.Marshal(opts)
+	if err != nil {
+		return methodProtoOptions{}, false
+	}
+	parsed := &descriptorpb.MethodOptions{}
+	if err := (proto.UnmarshalOptions{Resolver: resolver}).Unmarshal(b, parsed); err != nil {
+		return methodProtoOptions{}, false
+	}
+	r := parsed.ProtoReflect()
+	if !r.Has(xd) {
+		return methodProtoOptions{}, false
+	}
+
+	var m methodProtoOptions
+	v := r.Get(xd).Message()
+	fields := v.Descriptor().Fields()
+	if f := fields.ByName("skip"); f != nil && f.Kind() == protoreflect.BoolKind && v.Has(f) {
+		m.skip = v.Get(f).Bool()
+	}
+	if f := fields.ByName("payloads"); f != nil && f.Kind() == protoreflect.BoolKind && v.Has(f) {
+		payloads := v.Get(f).Bool()
+		m.payloads = &payloads
+	}
+	if f := fields.ByName("level"); f != nil && f.Kind() == protoreflect.StringKind && v.Has(f) {
+		if s := strings.ToLower(v.Get(f).String()); s != "" {
+			if level, err := zerolog.ParseLevel(s); err == nil {
+				m.level, m.levelIsSet = level, true
+			}
+		}
+	}
+	return m, true
+}
+
+// hasPayloadEvent reports whether the payload event is logged for the method
+func (o *options) hasPayloadEvent(fullMethod string, event LoggableEvent) bool {
+	if enabled, ok := o.methodPayloads[fullMethod]; ok {
+		return enabled
+	}
+	return o.hasEvent(event)
+}
@@ -0,0 +1,121 @@
+package grpc_zerolog
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// loggingOption encodes the (grpc_zerolog.example.logging) option of testdata/logging_options.proto as the unknown field,
+// like the options of the generated code parsed without the extension
+func loggingOption(fields func(b []byte) []byte) *descriptorpb.MethodOptions {
+	opts := &descriptorpb.MethodOptions{}
+	b := protowire.AppendTag(nil, 50000, protowire.BytesType)
+	b = protowire.AppendBytes(b, fields(nil))
+	opts.ProtoReflect().SetUnknown(b)
+	return opts
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(v))
+}
+
+// exampleFiles builds testdata/logging_options.proto
+func exampleFiles(t *testing.T) (*protoregistry.Files, protoreflect.ExtensionType) {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	method := func(name string, opts *descriptorpb.MethodOptions) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".grpc_zerolog.example.Empty"),
+			OutputType: proto.String(".grpc_zerolog.example.Empty"),
+			Options:    opts,
+		}
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("testdata/logging_options.proto"),
+		Package:    proto.String("grpc_zerolog.example"),
+		Syntax:     proto.String("proto2"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("LoggingOptions"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("skip"), Number: proto.Int32(1), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum()},
+				{Name: proto.String("payloads"), Number: proto.Int32(2), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum()},
+				{Name: proto.String("level"), Number: proto.Int32(3), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+			},
+		}, {
+			Name: proto.String("Empty"),
+		}},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("logging"),
+			Number:   proto.Int32(50000),
+			Label:    optional,
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".grpc_zerolog.example.LoggingOptions"),
+			Extendee: proto.String(".google.protobuf.MethodOptions"),
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("EchoService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Health", loggingOption(func(b []byte) []byte { return appendBool(b, 1, true) })),
+				method("Echo", loggingOption(func(b []byte) []byte {
+					b = appendBool(b, 2, true)
+					b = protowire.AppendTag(b, 3, protowire.BytesType)
+					return protowire.AppendString(b, "debug")
+				})),
+				method("Secret", loggingOption(func(b []byte) []byte { return appendBool(b, 2, false) })),
+				method("Plain", nil),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("failed to build the descriptor: %v", err)
+	}
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatalf("failed to register the descriptor: %v", err)
+	}
+	return files, dynamicpb.NewExtensionType(fd.Extensions().Get(0))
+}
+
+func TestProtoOptions(t *testing.T) {
+	files, ext := exampleFiles(t)
+	o := evaluateOptions([]Option{WithLogOnEvents(FinishCall, PayloadReceived), protoOptionsFrom(files, ext)})
+
+	const service = "/grpc_zerolog.example.EchoService/"
+	if o.decide(nil, service+"Health", nil) {
+		t.Error("the skipped method is logged")
+	}
+	if !o.decide(nil, service+"Echo", nil) {
+		t.Error("the annotated method is not logged")
+	}
+	if !o.hasPayloadEvent(service+"Echo", PayloadSent) {
+		t.Error("the payloads of Echo are not logged")
+	}
+	if o.hasPayloadEvent(service+"Secret", PayloadReceived) {
+		t.Error("the payloads of Secret are logged")
+	}
+	if !o.hasPayloadEvent(service+"Plain", PayloadReceived) || o.hasPayloadEvent(service+"Plain", PayloadSent) {
+		t.Error("the method without the option doesn't use the global events")
+	}
+	if got := o.finishLevel(service+"Echo", codes.OK); got != zerolog.DebugLevel {
+		t.Errorf("got level %v of Echo, want debug", got)
+	}
+	if got := o.finishLevel(service+"Echo", codes.Internal); got != zerolog.ErrorLevel {
+		t.Errorf("got level %v of the failed Echo, want error", got)
+	}
+	if got := o.finishLevel(service+"Plain", codes.OK); got != zerolog.InfoLevel {
+		t.Errorf("got level %v of Plain, want info", got)
+	}
+}
@@ -0,0 +1,64 @@
+package grpc_zerolog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+// WithPublicErrorsOnly omits the raw error messages from the FinishCall event, e.g. when the logs leave the company
+// and the messages may embed personal data or tokens. Instead of the error, status message, root cause, cancel cause
+// and panic value the event has "grpc.error_class" derived from the code and "grpc.error_hash", a short hash of the
+// full error string to group the identical errors. The message of WithStructuredStatus is omitted too.
+// The messages of the errors with the given codes (e.g. codes.InvalidArgument) are safe and logged verbatim.
+func WithPublicErrorsOnly(safeCodes ...codes.Code) Option {
+	safe := make(map[codes.Code]bool, len(safeCodes))
+	for _, c := range safeCodes {
+		safe[c] = true
+	}
+	return func(o *options) {
+		o.publicErrorsOnly = true
+		o.publicErrorCodes = safe
+	}
+}
+
+// WithErrorBooleanOnly omits the raw error messages like WithPublicErrorsOnly, but the events of the failed calls
+// have only "grpc.error.present": true instead of the error, for the compliance-sensitive services that mustn't log
+// even a hash of the messages. The code and its level are logged as usual. It takes precedence over WithPublicErrorsOnly.
+func WithErrorBooleanOnly() Option {
+	return func(o *options) {
+		o.errorBooleanOnly = true
+	}
+}
+
+// hidesErrorMessages reports whether the messages of the error with the code are omitted by WithPublicErrorsOnly
+// or WithErrorBooleanOnly
+func (o *options) hidesErrorMessages(code codes.Code) bool {
+	return o.errorBooleanOnly || (o.publicErrorsOnly && !o.publicErrorCodes[code])
+}
+
+// errorClass returns the class of the gRPC error code: "client" if the caller has to fix the request,
+// "transient" if the call can be retried, "server" otherwise
+func errorClass(code codes.Code) string {
+	switch code {
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.FailedPrecondition, codes.OutOfRange, codes.Unauthenticated:
+		return "client"
+	case codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Unavailable:
+		return "transient"
+	default:
+		return "server"
+	}
+}
+
+// withPublicError adds the error class and the short hash of the error string instead of the error,
+// or only the presence of the error for WithErrorBooleanOnly
+func (o *options) withPublicError(with zerolog.Context, code codes.Code, err error) zerolog.Context {
+	if o.errorBooleanOnly {
+		return with.Bool("grpc.error.present", true)
+	}
+	h := sha256.Sum256([]byte(err.Error()))
+	return with.Str("grpc.error_class", errorClass(code)).Str("grpc.error_hash", hex.EncodeToString(h[:8]))
+}
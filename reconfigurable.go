@@ -0,0 +1,59 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// Interceptor provides the server interceptors whose options can be changed at runtime by Reconfigure,
+// e.g. to enable the payload logging during an incident without a restart.
+type Interceptor struct {
+	logger  zerolog.Logger
+	current atomic.Value // *serverInterceptors
+}
+
+// serverInterceptors are the interceptors evaluated from a set of options
+type serverInterceptors struct {
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+}
+
+// NewInterceptor returns Interceptor logging to logger with the given options
+func NewInterceptor(logger zerolog.Logger, opts ...Option) *Interceptor {
+	i := &Interceptor{logger: logger}
+	i.Reconfigure(opts...)
+	return i
+}
+
+// Reconfigure replaces all the options of the interceptors, the options given to NewInterceptor or the previous
+// Reconfigure are not kept. The calls started before keep the previous options until they finish.
+// The state of the options (e.g. the counters of WithInflightCount) is kept only if the same Option value is given again.
+func (i *Interceptor) Reconfigure(opts ...Option) {
+	o := evaluateOptions(opts)
+	provider := o.fixedLogger(i.logger)
+	i.current.Store(&serverInterceptors{
+		unary:  o.unaryServerInterceptor(provider),
+		stream: o.streamServerInterceptor(provider),
+	})
+}
+
+func (i *Interceptor) load() *serverInterceptors {
+	return i.current.Load().(*serverInterceptors)
+}
+
+// UnaryServer returns the unary server interceptor using the current options of each call
+func (i *Interceptor) UnaryServer() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return i.load().unary(ctx, req, info, handler)
+	}
+}
+
+// StreamServer returns the streaming server interceptor using the current options of each call
+func (i *Interceptor) StreamServer() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return i.load().stream(srv, stream, info, handler)
+	}
+}
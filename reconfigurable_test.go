@@ -0,0 +1,40 @@
+package grpc_zerolog_test
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/pereslava/grpc_zerolog"
+	"github.com/rs/zerolog"
+)
+
+func TestInterceptorReconfigure(t *testing.T) {
+	b := &syncBuffer{}
+	i := grpc_zerolog.NewInterceptor(zerolog.New(b), grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall))
+	unary := i.UnaryServer()
+
+	wg := sync.WaitGroup{}
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				callUnary(unary, "request", nil)
+			}
+		}()
+	}
+	for n := 0; n < 10; n++ {
+		i.Reconfigure(grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall))
+	}
+	wg.Wait()
+
+	b.b = bytes.Buffer{}
+	i.Reconfigure(grpc_zerolog.WithLogOnEvents(grpc_zerolog.PayloadReceived))
+	callUnary(unary, "request", nil)
+	want := []string{"payload received"}
+	if got := messages(logLines(t, &b.b)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q after Reconfigure, want %q", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package grpc_zerolog
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+)
+
+// WithResponseValidator makes the server interceptors check the responses by f, i.e. the unary response and
+// each message sent by the stream. If f returns an error the FinishCall event has "grpc.response.invalid": true
+// and the error as "grpc.response.validation_error", the response is sent as is. The stream stops validating
+// after the first invalid message, its error is logged. The non-proto messages are not validated.
+func WithResponseValidator(f func(msg proto.Message) error) Option {
+	return func(o *options) {
+		o.responseValidator = f
+	}
+}
+
+// validateResponse keeps the validation error of the response m sent by the server
+func (o *options) validateResponse(ci *callInfo, m interface{}) {
+	if o.responseValidator == nil || !ci.server || ci.invalidResponse.Load() != nil {
+		return
+	}
+	p, ok := m.(proto.Message)
+	if !ok {
+		return
+	}
+	if err := o.responseValidator(p); err != nil {
+		ci.invalidResponse.Store(err.Error())
+	}
+}
+
+func (o *options) withInvalidResponse(ci *callInfo, with zerolog.Context) zerolog.Context {
+	if msg, ok := ci.invalidResponse.Load().(string); ok {
+		with = with.Bool("grpc.response.invalid", true).Str("grpc.response.validation_error", o.truncateValue(msg))
+	}
+	return with
+}
@@ -0,0 +1,79 @@
+package grpc_zerolog
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/status"
+)
+
+// maxUnwrapDepth caps the unwrapping of the errors in case of a cycle in the chain
+const maxUnwrapDepth = 100
+
+// WithRootCauseField adds the innermost error of the errors.Unwrap chain of the call error as "grpc.error.root_cause" field
+// of the FinishCall event. The field is omitted if it is the same as the error message.
+func WithRootCauseField() Option {
+	return func(o *options) {
+		o.rootCauseField = true
+	}
+}
+
+// rootCause returns the innermost error of the errors.Unwrap chain
+func rootCause(err error) error {
+	for i := 0; i < maxUnwrapDepth; i++ {
+		next := errors.Unwrap(err)
+		if next == nil {
+			break
+		}
+		err = next
+	}
+	return err
+}
+
+func (o *options) withRootCause(with zerolog.Context, err error) zerolog.Context {
+	if cause := rootCause(err).Error(); cause != err.Error() {
+		with = with.Str("grpc.error.root_cause", o.truncateValue(cause))
+	}
+	return with
+}
+
+// WithErrorOriginField adds the place where the call error originated (e.g. "orders/store.go:42") extracted by f
+// as "grpc.error.origin" field of the FinishCall event, a lighter alternative to logging the whole stack.
+// The extractor depends on the errors library capturing the stacks. The field is omitted if f returns the empty string.
+func WithErrorOriginField(f func(err error) string) Option {
+	return func(o *options) {
+		o.errorOrigin = f
+	}
+}
+
+func (o *options) withErrorOrigin(with zerolog.Context, err error) zerolog.Context {
+	if origin := o.errorOrigin(err); origin != "" {
+		with = with.Str("grpc.error.origin", o.truncateValue(origin))
+	}
+	return with
+}
+
+// WithErrorGroupingKey adds the key computed by f from the call error as "grpc.error.group" field of the FinishCall event,
+// so the errors can be aggregated regardless of their dynamic messages. Nil f is ErrorGroupByType.
+// The field is omitted if the call succeeded or f returns the empty string.
+func WithErrorGroupingKey(f func(err error) string) Option {
+	if f == nil {
+		f = ErrorGroupByType
+	}
+	return func(o *options) {
+		o.errorGroup = f
+	}
+}
+
+// ErrorGroupByType returns the gRPC code and the Go type of the root cause of err, e.g. "NotFound *store.NotFoundError"
+func ErrorGroupByType(err error) string {
+	return fmt.Sprintf("%s %T", status.Code(err), rootCause(err))
+}
+
+func (o *options) withErrorGroup(with zerolog.Context, err error) zerolog.Context {
+	if group := o.errorGroup(err); group != "" {
+		with = with.Str("grpc.error.group", o.truncateValue(group))
+	}
+	return with
+}
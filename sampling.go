@@ -0,0 +1,30 @@
+package grpc_zerolog
+
+import (
+	"github.com/rs/zerolog"
+)
+
+// WithZerologSampler samples the logged calls with the zerolog sampler, e.g. zerolog.BasicSampler or zerolog.BurstSampler.
+//
+// The sampling decision is made once per call (not per event, as logger.Sample(s) does) at the call start
+// with the level of codes.OK, so all the events of a single call are either logged or dropped together.
+// Error and higher level FinishCall events of the dropped calls are always logged.
+func WithZerologSampler(s zerolog.Sampler) Option {
+	return WithZerologLevelSampler(zerolog.LevelSampler{
+		TraceSampler: s,
+		DebugSampler: s,
+		InfoSampler:  s,
+		WarnSampler:  s,
+	})
+}
+
+// WithZerologLevelSampler samples the logged calls with the per-level zerolog sampler.
+//
+// The sampling decision is made once per call at the call start with the level of codes.OK.
+// Error and higher level FinishCall events of the dropped calls are logged if the sampler of that level samples them,
+// the nil ErrorSampler means all errors are logged.
+func WithZerologLevelSampler(s zerolog.LevelSampler) Option {
+	return func(o *options) {
+		o.sampler = &s
+	}
+}
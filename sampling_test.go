@@ -0,0 +1,44 @@
+package grpc_zerolog_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pereslava/grpc_zerolog"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestZerologSamplerSamplesCalls(t *testing.T) {
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b), grpc_zerolog.WithZerologSampler(&zerolog.BasicSampler{N: 2}))
+	for n := 0; n < 4; n++ {
+		callUnary(i, nil, nil)
+	}
+
+	lines := logLines(t, b)
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want start and finish of 2 calls", len(lines))
+	}
+	for n, want := range []string{"started unary call", "finished unary call", "started unary call", "finished unary call"} {
+		if got := lines[n]["message"]; got != want {
+			t.Errorf("line %d: got message %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestZerologSamplerExemptsErrors(t *testing.T) {
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewUnaryServerInterceptor(zerolog.New(b),
+		grpc_zerolog.WithZerologSampler(&zerolog.BasicSampler{N: 100}),
+		grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall),
+	)
+	for n := 0; n < 3; n++ {
+		callUnary(i, nil, status.Error(codes.Internal, "failed"))
+	}
+
+	if lines := logLines(t, b); len(lines) != 3 {
+		t.Fatalf("got %d lines, want all 3 errors logged", len(lines))
+	}
+}
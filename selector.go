@@ -0,0 +1,145 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// Selector builds server interceptors that use different options for different methods.
+//
+// The option set is picked by the full method name of the call. When several matchers match the method
+// the first added (by Match, MatchMethods or MatchServices) wins, the options of Default are used if nothing matches.
+// The methods and the services added by MatchMethods and MatchServices are compiled into maps when the interceptors
+// are created, so their per-call cost is a map lookup. The matchers added by Match are called in order
+// for every call of a method not resolved by the maps to an earlier route, prefer MatchMethods and MatchServices.
+// The Selector must be fully configured before the interceptors are created.
+type Selector struct {
+	logger      zerolog.Logger
+	routes      []selectorRoute
+	defaultOpts []Option
+}
+
+type selectorRoute struct {
+	matcher  Matcher // nil for the routes of MatchMethods and MatchServices
+	methods  []string
+	services []string
+	opts     []Option
+}
+
+// selectorTable is the resolution of the routes compiled when the interceptors are created
+type selectorTable struct {
+	methods  map[string]int // full method name -> the first route index
+	services map[string]int // service name -> the first route index
+	matchers []int          // the indexes of the routes of Match in order
+	matches  []Matcher
+}
+
+// NewSelector returns a Selector with the given logger used by all option sets
+func NewSelector(logger zerolog.Logger) *Selector {
+	return &Selector{logger: logger}
+}
+
+// Match adds the options used for the methods matched by the matcher
+func (s *Selector) Match(matcher Matcher, opts ...Option) *Selector {
+	s.routes = append(s.routes, selectorRoute{matcher: matcher, opts: opts})
+	return s
+}
+
+// MatchMethods adds the options used for the given full method names, like Match with MatchMethods
+func (s *Selector) MatchMethods(fullMethodNames []string, opts ...Option) *Selector {
+	s.routes = append(s.routes, selectorRoute{methods: fullMethodNames, opts: opts})
+	return s
+}
+
+// MatchServices adds the options used for all methods of the given services (e.g. "package.Service"),
+// like Match with MatchServices
+func (s *Selector) MatchServices(services []string, opts ...Option) *Selector {
+	s.routes = append(s.routes, selectorRoute{services: services, opts: opts})
+	return s
+}
+
+// Default sets the options used for the methods not matched by any matcher
+func (s *Selector) Default(opts ...Option) *Selector {
+	s.defaultOpts = opts
+	return s
+}
+
+func (s *Selector) compile() *selectorTable {
+	t := &selectorTable{methods: map[string]int{}, services: map[string]int{}}
+	for i, r := range s.routes {
+		if r.matcher != nil {
+			t.matchers = append(t.matchers, i)
+			t.matches = append(t.matches, r.matcher)
+			continue
+		}
+		for _, m := range r.methods {
+			if _, ok := t.methods[m]; !ok {
+				t.methods[m] = i
+			}
+		}
+		for _, svc := range r.services {
+			svc = strings.TrimPrefix(svc, "/")
+			if _, ok := t.services[svc]; !ok {
+				t.services[svc] = i
+			}
+		}
+	}
+	return t
+}
+
+// resolve returns the index of the route of the method, -1 for the default. Nothing is stored per method,
+// so the unknown method names don't grow the tables.
+func (t *selectorTable) resolve(fullMethodName string) int {
+	idx := -1
+	if i, ok := t.methods[fullMethodName]; ok {
+		idx = i
+	}
+	if i, ok := t.services[strings.TrimPrefix(path.Dir(fullMethodName), "/")]; ok && (idx < 0 || i < idx) {
+		idx = i
+	}
+	for j, i := range t.matchers {
+		if idx >= 0 && i > idx {
+			break
+		}
+		if t.matches[j](fullMethodName) {
+			return i
+		}
+	}
+	return idx
+}
+
+// UnaryServerInterceptor returns an unary server interceptor that logs the gRPC calls with the selected options
+func (s *Selector) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	def := NewUnaryServerInterceptor(s.logger, s.defaultOpts...)
+	routes := make([]grpc.UnaryServerInterceptor, len(s.routes))
+	for i, r := range s.routes {
+		routes[i] = NewUnaryServerInterceptor(s.logger, r.opts...)
+	}
+	table := s.compile()
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if i := table.resolve(info.FullMethod); i >= 0 {
+			return routes[i](ctx, req, info, handler)
+		}
+		return def(ctx, req, info, handler)
+	}
+}
+
+// StreamServerInterceptor returns a streaming server interceptor that logs the gRPC calls with the selected options
+func (s *Selector) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	def := NewStreamServerInterceptor(s.logger, s.defaultOpts...)
+	routes := make([]grpc.StreamServerInterceptor, len(s.routes))
+	for i, r := range s.routes {
+		routes[i] = NewStreamServerInterceptor(s.logger, r.opts...)
+	}
+	table := s.compile()
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if i := table.resolve(info.FullMethod); i >= 0 {
+			return routes[i](srv, stream, info, handler)
+		}
+		return def(srv, stream, info, handler)
+	}
+}
@@ -0,0 +1,41 @@
+package grpc_zerolog_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pereslava/grpc_zerolog"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+func TestSelectorPrecedence(t *testing.T) {
+	b := &bytes.Buffer{}
+	i := grpc_zerolog.NewSelector(zerolog.New(b)).
+		Match(grpc_zerolog.MatchPattern("/pkg.Service/*"), grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall)).
+		MatchMethods([]string{"/pkg.Service/A", "/pkg.Other/A"}, grpc_zerolog.WithLogOnEvents(grpc_zerolog.FinishCall)).
+		MatchServices([]string{"pkg.Other"}, grpc_zerolog.WithLogOnEvents(grpc_zerolog.PayloadReceived)).
+		Default(grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall, grpc_zerolog.FinishCall)).
+		UnaryServerInterceptor()
+
+	tests := []struct {
+		method string
+		want   []string
+	}{
+		{"/pkg.Service/A", []string{"started unary call"}},
+		{"/pkg.Other/A", []string{"finished unary call"}},
+		{"/pkg.Other/B", []string{"payload received"}},
+		{"/pkg.Unknown/A", []string{"started unary call", "finished unary call"}},
+	}
+	for _, tt := range tests {
+		b.Reset()
+		_, _ = i(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		if got := messages(logLines(t, b)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got events %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
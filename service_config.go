@@ -0,0 +1,59 @@
+package grpc_zerolog
+
+import (
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// WithServiceConfigField adds the method config of the service config applied to the client call as "grpc.service_config"
+// object of the FinishCall event of the client interceptors, e.g.
+//
+//	"grpc.service_config": {"timeout_ms": 500, "wait_for_ready": true, "retry": {"max_attempts": 3, "codes": ["UNAVAILABLE"]}}
+//
+// grpc-go exposes neither the name nor the source of the method config, so only its settings are logged.
+// The object is omitted if no method config applies to the call.
+func WithServiceConfigField() Option {
+	return func(o *options) {
+		o.serviceConfigField = true
+	}
+}
+
+// recordServiceConfig keeps the method config applied to the client call
+func (o *options) recordServiceConfig(ci *callInfo, cc *grpc.ClientConn) {
+	if !o.serviceConfigField || cc == nil {
+		return
+	}
+	mc := cc.GetMethodConfig(ci.fullMethod)
+	ci.methodConfig = &mc
+}
+
+func withServiceConfig(ci *callInfo, with zerolog.Context) zerolog.Context {
+	mc := ci.methodConfig
+	if mc.Timeout == nil && mc.WaitForReady == nil && mc.MaxReqSize == nil && mc.MaxRespSize == nil && mc.RetryPolicy == nil {
+		return with
+	}
+	d := zerolog.Dict()
+	if mc.Timeout != nil {
+		d = d.Dur("timeout_ms", *mc.Timeout)
+	}
+	if mc.WaitForReady != nil {
+		d = d.Bool("wait_for_ready", *mc.WaitForReady)
+	}
+	if mc.MaxReqSize != nil {
+		d = d.Int("max_request_bytes", *mc.MaxReqSize)
+	}
+	if mc.MaxRespSize != nil {
+		d = d.Int("max_response_bytes", *mc.MaxRespSize)
+	}
+	if p := mc.RetryPolicy; p != nil {
+		retryCodes := make([]string, 0, len(p.RetryableStatusCodes))
+		for c := codes.OK; c <= maxSummaryCode; c++ {
+			if p.RetryableStatusCodes[c] {
+				retryCodes = append(retryCodes, canonicalCodeName(c))
+			}
+		}
+		d = d.Dict("retry", zerolog.Dict().Int("max_attempts", p.MaxAttempts).Strs("codes", retryCodes))
+	}
+	return with.Dict("grpc.service_config", d)
+}
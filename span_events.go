@@ -0,0 +1,56 @@
+package grpc_zerolog
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Span is the recording span of the call, e.g. the adapter of trace.Span of OpenTelemetry
+type Span interface {
+	// AddEvent records the event with the attributes
+	AddEvent(name string, attributes map[string]interface{})
+	// SetError marks the span as failed with the gRPC code and the status message
+	SetError(code codes.Code, message string)
+}
+
+// SpanExtractor function returns the recording span stored in the call context, false if there is none
+type SpanExtractor func(ctx context.Context) (Span, bool)
+
+// WithSpanEvents records every logged event as the event of the span of the call, named after the LoggableEvent
+// and with the fields of the log line as the attributes, without the dependency on OpenTelemetry, e.g.
+//
+//	grpc_zerolog.WithSpanEvents(func(ctx context.Context) (grpc_zerolog.Span, bool) {
+//		span := trace.SpanFromContext(ctx)
+//		return otelSpan{span}, span.IsRecording()
+//	})
+//
+// The failed calls also set the error of the span, even if the FinishCall event is not logged. The span is read when the call starts, so the interceptor
+// must be chained after the one starting the span. The attributes are rendered like by WithEventCallback.
+func WithSpanEvents(extract SpanExtractor) Option {
+	return func(o *options) {
+		o.spanExtractor = extract
+	}
+}
+
+// initSpan reads the span of WithSpanEvents from the call context
+func (o *options) initSpan(ctx context.Context, ci *callInfo) {
+	if o.spanExtractor == nil || ctx == nil {
+		return
+	}
+	if span, ok := o.spanExtractor(ctx); ok && span != nil {
+		ci.span = span
+	}
+}
+
+// setSpanError sets the error of the span of the failed call
+func (o *options) setSpanError(ci *callInfo, code codes.Code, callError error) {
+	if ci.span == nil || callError == nil {
+		return
+	}
+	message := statusFromError(callError).Message()
+	if o.hidesErrorMessages(code) {
+		message = ""
+	}
+	ci.span.SetError(code, message)
+}
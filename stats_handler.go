@@ -0,0 +1,77 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+// grpcMessageHeaderLength is the length of the gRPC message prefix counted in the wire length, but not in the payload length
+const grpcMessageHeaderLength = 5
+
+type callStatsKey struct{}
+
+// callStats accumulates the sizes of the received payloads and the time of the response header reported by the stats handler
+type callStats struct {
+	wire         int64
+	uncompressed int64
+	header       int64 // the time of the response header in Unix nanoseconds, zero if not received
+	compressed   int32 // 1 if the response is compressed
+}
+
+// NewClientStatsHandler returns the stats handler that provides the payload sizes and the header time to the client interceptors,
+// it must be registered on the connection by grpc.WithStatsHandler to log the fields like "grpc.response.compression_ratio"
+// or "grpc.time_to_header_ms"
+func NewClientStatsHandler() stats.Handler {
+	return clientStatsHandler{}
+}
+
+type clientStatsHandler struct{}
+
+func (clientStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (clientStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	cs, ok := ctx.Value(callStatsKey{}).(*callStats)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.InHeader:
+		atomic.CompareAndSwapInt64(&cs.header, 0, time.Now().UnixNano())
+		if s.Compression != "" && s.Compression != "identity" {
+			atomic.StoreInt32(&cs.compressed, 1)
+		}
+	case *stats.InPayload:
+		atomic.AddInt64(&cs.wire, int64(s.WireLength-grpcMessageHeaderLength))
+		atomic.AddInt64(&cs.uncompressed, int64(s.Length))
+	}
+}
+
+func (clientStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (clientStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+// compressionRatio returns the ratio of the wire size to the uncompressed size of the received payloads,
+// ok is false if the response is not compressed or the sizes are unknown
+func (s *callStats) compressionRatio() (ratio float64, ok bool) {
+	wire, uncompressed := atomic.LoadInt64(&s.wire), atomic.LoadInt64(&s.uncompressed)
+	if atomic.LoadInt32(&s.compressed) == 0 || wire <= 0 || uncompressed <= 0 {
+		return 0, false
+	}
+	return float64(wire) / float64(uncompressed), true
+}
+
+// timeToHeader returns the time from the start of the call to the response header, ok is false if the header is not received
+func (s *callStats) timeToHeader(start time.Time) (d time.Duration, ok bool) {
+	header := atomic.LoadInt64(&s.header)
+	if header == 0 {
+		return 0, false
+	}
+	return time.Unix(0, header).Sub(start), true
+}
@@ -0,0 +1,105 @@
+package grpc_zerolog
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeNames are the canonical names of the gRPC codes as in google.rpc.Code
+var codeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+func canonicalCodeName(c codes.Code) string {
+	if n, ok := codeNames[c]; ok {
+		return n
+	}
+	return strings.ToUpper(c.String())
+}
+
+// statusFromError converts the error to the gRPC status, unlike status.Convert it looks for the status in the wrapped errors too
+func statusFromError(err error) *status.Status {
+	var se interface {
+		GRPCStatus() *status.Status
+	}
+	if errors.As(err, &se) {
+		return se.GRPCStatus()
+	}
+	return status.Convert(err)
+}
+
+// withStructuredStatus adds the status of the failed call as "grpc.status" object, the message is omitted if withMessage is false
+func withStructuredStatus(with zerolog.Context, err error, withMessage bool) zerolog.Context {
+	s := statusFromError(err)
+	d := zerolog.Dict().
+		Str("code", canonicalCodeName(s.Code())).
+		Uint32("code_int", uint32(s.Code()))
+	if withMessage {
+		d = d.Str("message", s.Message())
+	}
+	return with.Dict("grpc.status", d)
+}
+
+// CodeToHTTPStatus maps the gRPC code to the HTTP status the same way grpc-gateway does
+func CodeToHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.Unknown:
+		return http.StatusInternalServerError
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		// the request can't be retried as is, see google.rpc.Code
+		return http.StatusBadRequest
+	case codes.Aborted:
+		return http.StatusConflict
+	case codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Internal:
+		return http.StatusInternalServerError
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DataLoss:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusInternalServerError
+	}
+}
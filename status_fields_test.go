@@ -0,0 +1,39 @@
+package grpc_zerolog_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pereslava/grpc_zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+func TestCodeToHTTPStatus(t *testing.T) {
+	want := map[codes.Code]int{
+		codes.OK:                 http.StatusOK,
+		codes.Canceled:           499,
+		codes.Unknown:            http.StatusInternalServerError,
+		codes.InvalidArgument:    http.StatusBadRequest,
+		codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+		codes.NotFound:           http.StatusNotFound,
+		codes.AlreadyExists:      http.StatusConflict,
+		codes.PermissionDenied:   http.StatusForbidden,
+		codes.ResourceExhausted:  http.StatusTooManyRequests,
+		codes.FailedPrecondition: http.StatusBadRequest,
+		codes.Aborted:            http.StatusConflict,
+		codes.OutOfRange:         http.StatusBadRequest,
+		codes.Unimplemented:      http.StatusNotImplemented,
+		codes.Internal:           http.StatusInternalServerError,
+		codes.Unavailable:        http.StatusServiceUnavailable,
+		codes.DataLoss:           http.StatusInternalServerError,
+		codes.Unauthenticated:    http.StatusUnauthorized,
+	}
+	if len(want) != 17 {
+		t.Fatalf("the table covers %d codes, want 17", len(want))
+	}
+	for code, status := range want {
+		if got := grpc_zerolog.CodeToHTTPStatus(code); got != status {
+			t.Errorf("CodeToHTTPStatus(%s) = %d, want %d", code, got, status)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package grpc_zerolog
+
+import (
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+)
+
+// WithStreamByteTotals adds the total serialized size of the messages successfully sent and received by the stream
+// as "grpc.send.bytes" and "grpc.recv.bytes" fields of the stream FinishCall event. The non-proto messages are not
+// counted, "grpc.bytes_partial" field is set if there were any.
+func WithStreamByteTotals() Option {
+	return func(o *options) {
+		o.streamByteTotals = true
+	}
+}
+
+// countBytes adds the size of the stream message m to the totals of WithStreamByteTotals
+func (o *options) countBytes(ci *callInfo, sent bool, m interface{}) {
+	if !ci.countsBytes {
+		return
+	}
+	p, ok := m.(proto.Message)
+	if !ok {
+		atomic.StoreInt32(&ci.bytesPartial, 1)
+		return
+	}
+	if sent {
+		atomic.AddInt64(&ci.sentBytes, int64(proto.Size(p)))
+	} else {
+		atomic.AddInt64(&ci.receivedBytes, int64(proto.Size(p)))
+	}
+}
+
+func withStreamBytes(ci *callInfo, with zerolog.Context) zerolog.Context {
+	with = with.Int64("grpc.send.bytes", atomic.LoadInt64(&ci.sentBytes)).
+		Int64("grpc.recv.bytes", atomic.LoadInt64(&ci.receivedBytes))
+	if atomic.LoadInt32(&ci.bytesPartial) != 0 {
+		with = with.Bool("grpc.bytes_partial", true)
+	}
+	return with
+}
@@ -0,0 +1,33 @@
+package grpc_zerolog
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// WithFinishOnFirstError makes the stream interceptors log the FinishCall event with the error of the first failed
+// SendMsg or RecvMsg (io.EOF is not a failure) instead of the error returned by the handler, and stop logging
+// the messages of the stream after it. It only affects the logging, the stream and the handler work as usual.
+// The call is not counted as in flight (see WithInflightCount) after the FinishCall event.
+func WithFinishOnFirstError() Option {
+	return func(o *options) {
+		o.finishOnFirstError = true
+	}
+}
+
+// finishOnStreamError finishes the stream call on the failed SendMsg or RecvMsg if WithFinishOnFirstError is set
+func (o *options) finishOnStreamError(ci *callInfo, err error) {
+	if !o.finishOnFirstError || err == io.EOF {
+		return
+	}
+	msg := msgClientStream
+	if ci.server {
+		msg = msgServerStream
+	}
+	o.finish(ci, err, msg)
+}
+
+// finishedOnError reports whether the messages of the stream are not logged anymore because of WithFinishOnFirstError
+func (o *options) finishedOnError(ci *callInfo) bool {
+	return o.finishOnFirstError && atomic.LoadInt32(&ci.finished) == 1
+}
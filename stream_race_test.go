@@ -0,0 +1,136 @@
+package grpc_zerolog_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pereslava/grpc_zerolog"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The tests drive the wrapped streams from several goroutines, they are meant to be run with -race.
+// More than one goroutine sends and receives, it is stricter than the contract of the gRPC streams.
+
+const (
+	raceSenders   = 4
+	raceReceivers = 2
+	raceMessages  = 50
+)
+
+// syncBuffer is the log writer safe for the concurrent events
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (w *syncBuffer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func raceOptions() []grpc_zerolog.Option {
+	return []grpc_zerolog.Option{
+		grpc_zerolog.WithLogOnEvents(grpc_zerolog.StartCall, grpc_zerolog.FinishCall, grpc_zerolog.PayloadReceived, grpc_zerolog.PayloadSent,
+			grpc_zerolog.StreamOpened, grpc_zerolog.PayloadSendFailed, grpc_zerolog.PayloadReceiveFailed),
+		grpc_zerolog.WithInFlightLogging(time.Millisecond),
+		grpc_zerolog.WithMaxPayloadEventsPerCall(raceMessages),
+		grpc_zerolog.WithPayloadOnCodes(codes.OK, codes.Unavailable),
+		grpc_zerolog.WithPerMessageSizeLogging(),
+		grpc_zerolog.WithStreamByteTotals(),
+		grpc_zerolog.WithMessageTypeFields(),
+		grpc_zerolog.WithInflightCount(),
+		grpc_zerolog.WithTimeToHeader(),
+	}
+}
+
+// raceStream is the underlying stream failing every third send and ending after the given number of received messages
+type raceStream struct {
+	grpc.ServerStream
+	grpc.ClientStream
+	sent     int64
+	received int64
+}
+
+func (s *raceStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *raceStream) SendMsg(m interface{}) error {
+	if atomic.AddInt64(&s.sent, 1)%3 == 0 {
+		return status.Error(codes.Unavailable, "send failed")
+	}
+	return nil
+}
+
+func (s *raceStream) RecvMsg(m interface{}) error {
+	n := atomic.AddInt64(&s.received, 1)
+	if n > raceMessages {
+		return io.EOF
+	}
+	if n%7 == 0 {
+		return status.Error(codes.Unavailable, "receive failed")
+	}
+	m.(*wrapperspb.StringValue).Value = "message"
+	return nil
+}
+
+// driveStream calls SendMsg, RecvMsg and Context of the stream concurrently
+func driveStream(send func(m interface{}) error, recv func(m interface{}) error, ctx func() context.Context) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < raceSenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < raceMessages; j++ {
+				_ = send(wrapperspb.String("message"))
+				zerolog.Ctx(ctx()).Debug().Msg("sent")
+			}
+		}()
+	}
+	for i := 0; i < raceReceivers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < raceMessages; j++ {
+				m := &wrapperspb.StringValue{}
+				if err := recv(m); err == io.EOF {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStreamServerIsRaceFree(t *testing.T) {
+	i := grpc_zerolog.NewStreamServerInterceptor(zerolog.New(&syncBuffer{}).Level(zerolog.DebugLevel), raceOptions()...)
+	err := i(nil, &raceStream{}, &grpc.StreamServerInfo{FullMethod: testMethod}, func(srv interface{}, stream grpc.ServerStream) error {
+		driveStream(stream.SendMsg, stream.RecvMsg, stream.Context)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestStreamClientIsRaceFree(t *testing.T) {
+	i := grpc_zerolog.NewStreamClientInterceptor(zerolog.New(&syncBuffer{}), raceOptions()...)
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &raceStream{}, nil
+	}
+	cs, err := i(context.Background(), &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, nil, testMethod, streamer)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	driveStream(cs.SendMsg, cs.RecvMsg, cs.Context)
+}
@@ -0,0 +1,187 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+const msgSummary = "calls summary"
+
+// summaryBuckets are the upper bounds of the duration histogram buckets, the last bucket is unbounded
+var summaryBuckets = func() []time.Duration {
+	b := make([]time.Duration, 0, 24)
+	for d := 100 * time.Microsecond; d <= 2*time.Minute; d *= 2 {
+		b = append(b, d)
+	}
+	return b
+}()
+
+// maxSummaryCode is the greatest gRPC code counted separately, the greater codes are counted as codes.Unknown
+const maxSummaryCode = codes.Unauthenticated
+
+// WithSummary accumulates per-method counters of the calls and logs one summary line per method every interval
+// with the number of calls, the errors by codes and p50/p99 durations. The methods with no calls in the interval are not logged.
+// The summary is logged by the background goroutine that stops when ctx is done.
+// The interceptors created with the same returned Option share the counters.
+//
+// Combine it with WithDecider to restrict the per-call logs e.g. to errors only.
+// The option does nothing if interval isn't positive.
+func WithSummary(ctx context.Context, interval time.Duration, summaryLogger zerolog.Logger) Option {
+	if interval <= 0 {
+		return func(*options) {}
+	}
+	r := newSummaryReporter(interval, summaryLogger)
+	go r.run(ctx)
+	return func(o *options) {
+		o.summary = r
+	}
+}
+
+// WithPeriodicSummary is like WithSummary, but the summary lines replace the FinishCall lines of the methods
+// set by WithPeriodicSummaryMethods (all methods if it isn't given). The calls of the last interval are logged
+// when ctx is done, so cancel it on shutdown after the server is stopped. The option does nothing if interval isn't positive.
+func WithPeriodicSummary(ctx context.Context, interval time.Duration, summaryLogger zerolog.Logger) Option {
+	if interval <= 0 {
+		return func(*options) {}
+	}
+	r := newSummaryReporter(interval, summaryLogger)
+	go r.run(ctx)
+	return func(o *options) {
+		o.periodicSummary = r
+	}
+}
+
+// WithPeriodicSummaryMethods restricts WithPeriodicSummary to the given full method names, e.g. "/pkg.Service/Method"
+func WithPeriodicSummaryMethods(methods ...string) Option {
+	return func(o *options) {
+		o.periodicSummaryMethods = make(map[string]bool, len(methods))
+		for _, m := range methods {
+			o.periodicSummaryMethods[m] = true
+		}
+	}
+}
+
+// summarizes reports whether the FinishCall event of the method is replaced by the periodic summary
+func (o *options) summarizes(fullMethod string) bool {
+	return o.periodicSummary != nil && (o.periodicSummaryMethods == nil || o.periodicSummaryMethods[fullMethod])
+}
+
+type summaryReporter struct {
+	interval time.Duration
+	logger   zerolog.Logger
+	methods  sync.Map // full method name -> *methodSummary
+}
+
+type methodSummary struct {
+	calls   int64
+	codes   [maxSummaryCode + 1]int64
+	buckets []int64
+}
+
+func newSummaryReporter(interval time.Duration, logger zerolog.Logger) *summaryReporter {
+	return &summaryReporter{interval: interval, logger: logger}
+}
+
+func (r *summaryReporter) observe(fullMethod string, code codes.Code, d time.Duration) {
+	v, ok := r.methods.Load(fullMethod)
+	if !ok {
+		v, _ = r.methods.LoadOrStore(fullMethod, &methodSummary{buckets: make([]int64, len(summaryBuckets)+1)})
+	}
+	m := v.(*methodSummary)
+
+	atomic.AddInt64(&m.calls, 1)
+	if code > maxSummaryCode {
+		code = codes.Unknown
+	}
+	atomic.AddInt64(&m.codes[code], 1)
+	i := 0
+	for i < len(summaryBuckets) && d > summaryBuckets[i] {
+		i++
+	}
+	atomic.AddInt64(&m.buckets[i], 1)
+}
+
+func (r *summaryReporter) run(ctx context.Context) {
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.report()
+			return
+		case <-t.C:
+			r.report()
+		}
+	}
+}
+
+// report logs and resets the counters of all methods
+func (r *summaryReporter) report() {
+	r.methods.Range(func(k, v interface{}) bool {
+		m := v.(*methodSummary)
+		calls := atomic.SwapInt64(&m.calls, 0)
+		if calls == 0 {
+			return true
+		}
+
+		var errors int64
+		codesDict := zerolog.Dict()
+		for c := range m.codes {
+			n := atomic.SwapInt64(&m.codes[c], 0)
+			if n == 0 {
+				continue
+			}
+			if codes.Code(c) != codes.OK {
+				errors += n
+			}
+			codesDict = codesDict.Int64(codes.Code(c).String(), n)
+		}
+		buckets := make([]int64, len(m.buckets))
+		for i := range m.buckets {
+			buckets[i] = atomic.SwapInt64(&m.buckets[i], 0)
+		}
+
+		fullMethod := k.(string)
+		r.logger.Info().
+			Str("grpc.service", path.Dir(fullMethod)[1:]).
+			Str("grpc.method", path.Base(fullMethod)).
+			Dur("grpc.summary.interval_ms", r.interval).
+			Int64("grpc.summary.calls", calls).
+			Int64("grpc.summary.errors", errors).
+			Dict("grpc.summary.codes", codesDict).
+			Dur("grpc.summary.p50_ms", bucketsPercentile(buckets, 0.50)).
+			Dur("grpc.summary.p99_ms", bucketsPercentile(buckets, 0.99)).
+			Msg(msgSummary)
+		return true
+	})
+}
+
+// bucketsPercentile returns the upper bound of the bucket containing the percentile p,
+// the durations that exceed all buckets are reported as the greatest bucket bound
+func bucketsPercentile(buckets []int64, p float64) time.Duration {
+	var total int64
+	for _, n := range buckets {
+		total += n
+	}
+	rank := int64(float64(total)*p + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range buckets {
+		seen += n
+		if seen >= rank {
+			if i < len(summaryBuckets) {
+				return summaryBuckets[i]
+			}
+			break
+		}
+	}
+	return summaryBuckets[len(summaryBuckets)-1]
+}
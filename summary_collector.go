@@ -0,0 +1,61 @@
+package grpc_zerolog
+
+import (
+	"path"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// CallSummary describes the finished call for the collector of WithSummaryCollector
+type CallSummary struct {
+	Service          string
+	Method           string
+	Server           bool // the call is handled by the server interceptor
+	Stream           bool
+	Code             codes.Code
+	Duration         time.Duration
+	Error            error  // nil if the call succeeded
+	Peer             string // the address of the remote side, empty if unknown
+	SentMessages     int64
+	ReceivedMessages int64
+	SentBytes        int64 // the serialized size of the proto messages sent, the other messages are not counted
+	ReceivedBytes    int64
+}
+
+// WithSummaryCollector calls f with the summary of every finished call, e.g. to feed custom metrics or to check
+// the calls in tests. It is called for all calls whether the FinishCall event is logged or not (e.g. it's sampled out,
+// filtered by WithDecider or summarized by WithPeriodicSummary). f is called synchronously by the finishing call.
+// The peer of the client call is known only if the call reached the server.
+func WithSummaryCollector(f func(CallSummary)) Option {
+	return func(o *options) {
+		o.summaryCollector = f
+	}
+}
+
+func (o *options) collectSummary(ci *callInfo, callError error, elapsed time.Duration) {
+	s := CallSummary{
+		Service:          path.Dir(ci.fullMethod)[1:],
+		Method:           path.Base(ci.fullMethod),
+		Server:           ci.server,
+		Stream:           ci.stream,
+		Code:             status.Code(callError),
+		Duration:         elapsed,
+		Error:            callError,
+		SentMessages:     atomic.LoadInt64(&ci.sentMessages),
+		ReceivedMessages: atomic.LoadInt64(&ci.receivedMessages),
+		SentBytes:        atomic.LoadInt64(&ci.sentBytes),
+		ReceivedBytes:    atomic.LoadInt64(&ci.receivedBytes),
+	}
+	p := ci.peer
+	if ci.server && ci.ctx != nil {
+		p, _ = peer.FromContext(ci.ctx)
+	}
+	if p != nil && p.Addr != nil {
+		s.Peer = p.Addr.String()
+	}
+	o.summaryCollector(s)
+}
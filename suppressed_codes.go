@@ -0,0 +1,71 @@
+package grpc_zerolog
+
+import (
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+// suppressedCodes forces the level of the FinishCall events with the given codes
+type suppressedCodes struct {
+	match Matcher // nil matches all the methods
+	level zerolog.Level
+	codes map[codes.Code]bool
+}
+
+// WithSuppressedCodes logs the FinishCall events of the calls finished with the given codes at the given level
+// (typically Debug) regardless of the CodeToLevel function. The events still have the code and error fields.
+// E.g. use it for the expected business errors like NotFound of a lookup service.
+func WithSuppressedCodes(level zerolog.Level, c ...codes.Code) Option {
+	return WithSuppressedCodesMatching(nil, level, c...)
+}
+
+// WithSuppressedCodesMatching is like WithSuppressedCodes but applies only to the methods matched by m
+func WithSuppressedCodesMatching(m Matcher, level zerolog.Level, c ...codes.Code) Option {
+	s := suppressedCodes{match: m, level: level, codes: make(map[codes.Code]bool, len(c))}
+	for _, code := range c {
+		s.codes[code] = true
+	}
+	return func(o *options) {
+		o.suppressedCodes = append(o.suppressedCodes, s)
+	}
+}
+
+// WithMethodCodeLevels logs the FinishCall events at the level given for the full method name and the code, e.g.
+//
+//	grpc_zerolog.WithMethodCodeLevels(map[string]map[codes.Code]zerolog.Level{
+//		"/payments.Payments/Charge":   {codes.Unavailable: zerolog.ErrorLevel},
+//		"/telemetry.Telemetry/Report": {codes.Unavailable: zerolog.DebugLevel},
+//	})
+//
+// The pairs take precedence over WithSuppressedCodes and the CodeToLevel function, the other calls use them as usual.
+// The option may be given more than once, the later pairs replace the earlier ones.
+func WithMethodCodeLevels(m map[string]map[codes.Code]zerolog.Level) Option {
+	return func(o *options) {
+		if o.methodCodeLevels == nil {
+			o.methodCodeLevels = make(map[string]map[codes.Code]zerolog.Level, len(m))
+		}
+		for method, levels := range m {
+			merged := make(map[codes.Code]zerolog.Level, len(levels))
+			for c, l := range o.methodCodeLevels[method] {
+				merged[c] = l
+			}
+			for c, l := range levels {
+				merged[c] = l
+			}
+			o.methodCodeLevels[method] = merged
+		}
+	}
+}
+
+// finishLevel returns the level of the FinishCall event, WithMethodCodeLevels wins, then the first matching WithSuppressedCodes
+func (o *options) finishLevel(fullMethod string, code codes.Code) zerolog.Level {
+	if l, ok := o.methodCodeLevels[fullMethod][code]; ok {
+		return l
+	}
+	for _, s := range o.suppressedCodes {
+		if s.codes[code] && (s.match == nil || s.match(fullMethod)) {
+			return s.level
+		}
+	}
+	return o.levelFunc(code)
+}
@@ -0,0 +1,81 @@
+package grpc_zerolog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/rs/zerolog"
+)
+
+// TraceIDExtractor function returns the hex trace ID of the valid span context stored in the call context,
+// false if there is none, e.g. for OpenTelemetry
+//
+//	func(ctx context.Context) (string, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.HasTraceID()
+//	}
+type TraceIDExtractor func(ctx context.Context) (string, bool)
+
+// WithRequestIDFromTrace adds the "grpc.request.id" field to every event of the call, the trace ID returned by extract
+// to correlate the logs with the traces, or a fresh random ID of the same format if the call has no span.
+// The trace ID is read when the call starts, so the interceptor must be chained after the one starting the span.
+func WithRequestIDFromTrace(extract TraceIDExtractor) Option {
+	return func(o *options) {
+		o.requestIDExtractor = extract
+	}
+}
+
+// initRequestID sets the request ID of WithRequestIDFromTrace once per call
+func (o *options) initRequestID(ctx context.Context, ci *callInfo) {
+	if o.requestIDExtractor == nil {
+		return
+	}
+	if ctx != nil {
+		if id, ok := o.requestIDExtractor(ctx); ok && id != "" {
+			ci.requestID = id
+			return
+		}
+	}
+	ci.requestID = newRequestID()
+}
+
+// newRequestID returns the random ID of 32 hex characters like the trace ID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func withRequestID(ci *callInfo, with zerolog.Context) zerolog.Context {
+	return with.Str("grpc.request.id", ci.requestID)
+}
+
+// WithShortTraceID adds the "trace_id_short" field to every event of the call, the first chars characters of the
+// trace ID returned by extract, or the whole ID if it is shorter. The option is ignored if chars is not positive.
+func WithShortTraceID(extract TraceIDExtractor, chars int) Option {
+	return func(o *options) {
+		if chars <= 0 {
+			return
+		}
+		o.shortTraceIDExtractor = extract
+		o.shortTraceIDChars = chars
+	}
+}
+
+// initShortTraceID sets the short trace ID of WithShortTraceID once per call
+func (o *options) initShortTraceID(ctx context.Context, ci *callInfo) {
+	if o.shortTraceIDExtractor == nil || ctx == nil {
+		return
+	}
+	id, ok := o.shortTraceIDExtractor(ctx)
+	if !ok {
+		return
+	}
+	if len(id) > o.shortTraceIDChars {
+		id = id[:o.shortTraceIDChars]
+	}
+	ci.shortTraceID = id
+}
@@ -0,0 +1,60 @@
+package grpc_zerolog
+
+import (
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+// truncatedMarker is appended to the string field values truncated by WithMaxFieldValueLength
+const truncatedMarker = "...truncated"
+
+// WithMaxFieldValueLength truncates the string field values written by the interceptors (metadata, tags,
+// error messages) to n runes followed by the "...truncated" marker. It doesn't apply to the payload content,
+// see WithMaxPayloadSize. Zero means unlimited, it is the default.
+func WithMaxFieldValueLength(n int) Option {
+	return func(o *options) {
+		o.maxFieldValueLength = n
+	}
+}
+
+// truncateValue truncates the string field value to the length set by WithMaxFieldValueLength
+func (o *options) truncateValue(s string) string {
+	return truncateRunes(s, o.maxFieldValueLength, truncatedMarker)
+}
+
+// withError adds the error field, truncated if WithMaxFieldValueLength is set
+func (o *options) withError(with zerolog.Context, err error) zerolog.Context {
+	if o.maxFieldValueLength > 0 {
+		return with.Str(zerolog.ErrorFieldName, o.truncateValue(err.Error()))
+	}
+	return with.Err(err)
+}
+
+// truncateRunes truncates s to n runes followed by the suffix, non-positive n means unlimited
+func truncateRunes(s string, n int, suffix string) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	i, count := 0, 0
+	for i < len(s) && count < n {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		count++
+	}
+	if i >= len(s) {
+		return s
+	}
+	return s[:i] + suffix
+}
+
+// truncateBytes truncates s to at most n bytes without splitting a rune, non-positive n means unlimited
+func truncateBytes(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
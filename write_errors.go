@@ -0,0 +1,45 @@
+package grpc_zerolog
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithWriteErrorHandler makes the interceptor write the log lines to w and call f when w fails to write.
+// zerolog doesn't expose the write errors of the logger, so the writer of the logger given
+// to the interceptor constructor is replaced by w wrapped with the error-capturing writer.
+// The failed write never fails nor panics the gRPC call. f is called synchronously, so it must not block.
+func WithWriteErrorHandler(w io.Writer, f func(err error)) Option {
+	ew := &errorCapturingWriter{w: w, handler: f}
+	return func(o *options) {
+		o.output = ew
+	}
+}
+
+type errorCapturingWriter struct {
+	w       io.Writer
+	handler func(err error)
+}
+
+func (w *errorCapturingWriter) Write(p []byte) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.handle(fmt.Errorf("log writer panic: %v", r))
+		}
+		// the error is already handled, zerolog must not report it once more
+		n, err = len(p), nil
+	}()
+	if _, err := w.w.Write(p); err != nil {
+		w.handle(err)
+	}
+	return len(p), nil
+}
+
+func (w *errorCapturingWriter) handle(err error) {
+	defer func() {
+		_ = recover()
+	}()
+	if w.handler != nil {
+		w.handler(err)
+	}
+}